@@ -0,0 +1,305 @@
+// Command admin manages the notes table.
+//
+//	go run ./cmd/admin [-table name] [-region region] [-endpoint url] <command> [flags]
+//
+// Commands:
+//
+//	create-table                         create the table and its indexes if missing
+//	seed [-user file] [-topics file]     insert a user and their topics
+//	list-users                           print every user
+//	delete-user -id <id> | -email <e>    delete a user and everything they own
+//	dump-topic -user <id> -title <t>     print a topic and its notes as JSON
+//	reindex [-user <id>]                 recompute derived fields, one or all users
+//	migrate [-status] [-max-batches n]   apply pending schema migrations
+//
+// -endpoint points at DynamoDB Local, e.g. http://localhost:8000. The flags
+// default to the service configuration (NOTES_TABLE_NAME, NOTES_CONFIG_FILE,
+// ...). Credentials come from the default AWS configuration.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/config"
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, store *notes.Store, args []string) error
+}
+
+var commands = []command{
+	{"create-table", "", createTable},
+	{"seed", "[-user db/user.json] [-topics db/topics.json]", seed},
+	{"list-users", "", listUsers},
+	{"delete-user", "-id <id> | -email <email>", deleteUser},
+	{"dump-topic", "-user <id> -title <title>", dumpTopic},
+	{"reindex", "[-user <id>]", reindex},
+	{"migrate", "[-status] [-batch 100] [-max-batches n]", migrate},
+}
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config, %s", err)
+	}
+
+	table := flag.String("table", cfg.TableName, "DynamoDB table name")
+	region := flag.String("region", cfg.Region, "AWS region, from the environment if empty")
+	endpoint := flag.String("endpoint", cfg.Endpoint, "DynamoDB endpoint URL, e.g. for DynamoDB Local")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg.TableName, cfg.Region, cfg.Endpoint = *table, *region, *endpoint
+	err = cfg.Validate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err := notes.NewClient(context.Background(), cfg.Store())
+	if err != nil {
+		log.Fatal(err)
+	}
+	store := notes.NewStore(client, cfg.TableName)
+
+	for _, cmd := range commands {
+		if cmd.name == flag.Arg(0) {
+			err := cmd.run(context.Background(), store, flag.Args()[1:])
+			if err != nil {
+				log.Fatalf("%s: %s", cmd.name, err)
+			}
+			return
+		}
+	}
+
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "usage: %s [flags] <command> [command flags]\n\ncommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %s\n", strings.TrimSpace(cmd.name+" "+cmd.usage))
+	}
+	fmt.Fprintf(out, "\nflags:\n")
+	flag.PrintDefaults()
+}
+
+func createTable(ctx context.Context, store *notes.Store, args []string) error {
+	changes, err := store.EnsureTable(ctx)
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Printf("table %s is up to date\n", store.Table())
+	}
+	return nil
+}
+
+func seed(ctx context.Context, store *notes.Store, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	userFile := flags.String("user", "db/user.json", "user to insert")
+	topicsFile := flags.String("topics", "db/topics.json", "topics to insert for the user")
+	flags.Parse(args)
+
+	var insertUser notes.UserInsert
+	err := readJSON(*userFile, &insertUser)
+	if err != nil {
+		return err
+	}
+
+	var topics []notes.Topic
+	err = readJSON(*topicsFile, &topics)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("inserting: ", insertUser.Email)
+	user, err := store.InsertUser(ctx, insertUser)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.ID == "" {
+		return fmt.Errorf("user %q was not created", insertUser.Email)
+	}
+
+	for _, topic := range topics {
+		created, err := store.EnsureTopic(ctx, user.ID, topic.Title)
+		if err != nil {
+			return err
+		}
+		if created {
+			fmt.Println("inserted: ", topic.Title)
+		} else {
+			fmt.Println("exists: ", topic.Title)
+		}
+	}
+
+	return nil
+}
+
+func listUsers(ctx context.Context, store *notes.Store, args []string) error {
+	users, err := store.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tNAME")
+	for _, user := range users {
+		fmt.Fprintf(w, "%s\t%s\t%s %s\n", user.ID, user.Email, user.Name, user.Surname)
+	}
+	return w.Flush()
+}
+
+func deleteUser(ctx context.Context, store *notes.Store, args []string) error {
+	flags := flag.NewFlagSet("delete-user", flag.ExitOnError)
+	id := flags.String("id", "", "ID of the user")
+	email := flags.String("email", "", "email of the user")
+	flags.Parse(args)
+
+	if *id == "" && *email != "" {
+		user, err := store.GetUserByEmail(ctx, *email)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return fmt.Errorf("user %q: %w", *email, notes.ErrUserNotFound)
+		}
+		*id = user.ID
+	}
+	if *id == "" {
+		return fmt.Errorf("-id or -email is required")
+	}
+
+	err := store.DeleteUser(ctx, *id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("deleted user %s\n", *id)
+	return nil
+}
+
+func dumpTopic(ctx context.Context, store *notes.Store, args []string) error {
+	flags := flag.NewFlagSet("dump-topic", flag.ExitOnError)
+	userID := flags.String("user", "", "ID of the user")
+	title := flags.String("title", "", "title of the topic")
+	flags.Parse(args)
+
+	if *userID == "" || *title == "" {
+		return fmt.Errorf("-user and -title are required")
+	}
+
+	topic, err := store.GetUserTopicByTitle(ctx, *userID, *title)
+	if err != nil {
+		return err
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	return out.Encode(topic)
+}
+
+func reindex(ctx context.Context, store *notes.Store, args []string) error {
+	flags := flag.NewFlagSet("reindex", flag.ExitOnError)
+	userID := flags.String("user", "", "ID of the user, every user if empty")
+	flags.Parse(args)
+
+	if *userID != "" {
+		return reindexUser(ctx, store, *userID)
+	}
+
+	users, err := store.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		err = reindexUser(ctx, store, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reindexUser(ctx context.Context, store *notes.Store, userID string) error {
+	n, err := store.ReindexUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user %s, %w", userID, err)
+	}
+	fmt.Printf("reindexed %d topics of user %s\n", n, userID)
+	return nil
+}
+
+func migrate(ctx context.Context, store *notes.Store, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	status := flags.Bool("status", false, "only print which migrations have been applied")
+	batchSize := flags.Int("batch", 100, "items scanned per batch")
+	maxBatches := flags.Int("max-batches", 0, "stop after this many batches, 0 for no limit")
+	flags.Parse(args)
+
+	var records []notes.MigrationRecord
+	var err error
+	if *status {
+		records, err = store.GetMigrations(ctx)
+	} else {
+		records, err = store.Migrate(ctx, notes.MigrateOptions{
+			BatchSize:  int32(*batchSize),
+			MaxBatches: *maxBatches,
+		})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tITEMS\tSTATUS")
+	for _, record := range records {
+		state := "pending"
+		switch {
+		case record.AppliedAt != nil:
+			state = "applied " + record.AppliedAt.Format(time.RFC3339)
+		case !record.StartedAt.IsZero():
+			state = "in progress"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\n", record.Version, record.Name, record.Items, state)
+	}
+	w.Flush()
+
+	if errors.Is(err, notes.ErrMigrationsPending) {
+		fmt.Println("stopped after -max-batches, run migrate again to continue")
+		return nil
+	}
+	return err
+}
+
+func readJSON(fileName string, v any) error {
+	input, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("read file, %w", err)
+	}
+
+	err = json.Unmarshal(input, v)
+	if err != nil {
+		return fmt.Errorf("unmarshal %s, %w", fileName, err)
+	}
+
+	return nil
+}
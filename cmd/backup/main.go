@@ -0,0 +1,119 @@
+// Command backup saves a user's account to a JSON file and restores it.
+//
+//	go run ./cmd/backup export -user <id> [-o backup.json]
+//	go run ./cmd/backup restore -user <id> [-mode merge|replace] backup.json
+//
+// It reads the service configuration (NOTES_TABLE_NAME, NOTES_CONFIG_FILE,
+// ...) and the default AWS configuration, so set AWS_REGION and credentials
+// as for any other AWS tool.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/backup"
+	"github.com/KyleJonesNV/go-service-notes/pkg/config"
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config, %s", err)
+	}
+	client, err := notes.NewClient(context.Background(), cfg.Store())
+	if err != nil {
+		log.Fatal(err)
+	}
+	service := backup.New(notes.NewStore(client, cfg.TableName))
+
+	switch os.Args[1] {
+	case "export":
+		export(service, os.Args[2:])
+	case "restore":
+		restore(service, os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s export -user <id> [-o file]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s restore -user <id> [-mode merge|replace] <file>\n", os.Args[0])
+	os.Exit(2)
+}
+
+func export(service *backup.Service, args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	userID := flags.String("user", "", "ID of the user to back up")
+	output := flags.String("o", "", "file to write, standard output if empty")
+	flags.Parse(args)
+
+	if *userID == "" {
+		usage()
+	}
+
+	b, err := service.Create(context.Background(), *userID)
+	if err != nil {
+		log.Fatalf("backup, %s", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("create %s, %s", *output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	out := json.NewEncoder(w)
+	out.SetIndent("", "  ")
+	if err := out.Encode(b); err != nil {
+		log.Fatalf("write backup, %s", err)
+	}
+}
+
+func restore(service *backup.Service, args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	userID := flags.String("user", "", "ID of the user to restore into")
+	mode := flags.String("mode", backup.Merge, "merge into the account or replace its topics")
+	flags.Parse(args)
+
+	if *userID == "" || flags.NArg() != 1 {
+		usage()
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("open %s, %s", flags.Arg(0), err)
+	}
+	defer f.Close()
+
+	b, err := backup.Decode(f)
+	if err != nil {
+		log.Fatalf("read backup, %s", err)
+	}
+
+	result, err := service.Restore(context.Background(), *userID, b, *mode)
+	if err != nil {
+		log.Fatalf("restore, %s", err)
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	if err := out.Encode(result); err != nil {
+		log.Fatal(err)
+	}
+}
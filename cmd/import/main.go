@@ -0,0 +1,101 @@
+// Command import loads notes exported from another app into a user's topics.
+//
+//	go run ./cmd/import -user <id> [-format markdown|enex|notion] [-topic Inbox] [-dry-run] <file>
+//
+// markdown takes a zipped Obsidian-style vault, enex an Evernote notebook
+// export and notion a zipped Notion "Markdown & CSV" export. It reads the
+// service configuration (NOTES_TABLE_NAME, NOTES_CONFIG_FILE, ...) and the
+// default AWS configuration, so set AWS_REGION and credentials as for any
+// other AWS tool. The result is printed as JSON.
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/config"
+	"github.com/KyleJonesNV/go-service-notes/pkg/importer"
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+func main() {
+	userID := flag.String("user", "", "ID of the user to import into")
+	format := flag.String("format", "markdown", "export format: markdown, enex or notion")
+	topic := flag.String("topic", "", "topic for notes without a folder; for enex defaults to the file name")
+	dryRun := flag.Bool("dry-run", false, "show what would be created without writing")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -user <id> [flags] <file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *userID == "" || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	file := flag.Arg(0)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config, %s", err)
+	}
+	client, err := notes.NewClient(context.Background(), cfg.Store())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var parse func(sink importer.Sink)
+	switch *format {
+	case "markdown", "notion":
+		archive, err := zip.OpenReader(file)
+		if err != nil {
+			log.Fatalf("open %s, %s", file, err)
+		}
+		defer archive.Close()
+
+		parse = func(sink importer.Sink) {
+			if *format == "notion" {
+				importer.ParseNotion(&archive.Reader, sink)
+				return
+			}
+			importer.ParseVault(&archive.Reader, importer.VaultOptions{DefaultTopic: *topic}, sink)
+		}
+	case "enex":
+		f, err := os.Open(file)
+		if err != nil {
+			log.Fatalf("open %s, %s", file, err)
+		}
+		defer f.Close()
+
+		if *topic == "" {
+			*topic = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		}
+		parse = func(sink importer.Sink) {
+			importer.ParseENEX(f, filepath.Base(file), *topic, sink)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	result, err := importer.New(notes.NewStore(client, cfg.TableName)).Import(context.Background(), *userID, *dryRun, parse)
+	if err != nil {
+		log.Fatalf("import, %s", err)
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	if err := out.Encode(result); err != nil {
+		log.Fatal(err)
+	}
+	if len(result.Failed) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/config"
+	"github.com/KyleJonesNV/go-service-notes/pkg/logging"
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"github.com/KyleJonesNV/go-service-notes/pkg/reminders"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+var processor *reminders.Processor
+
+// handler runs on a schedule, e.g. an EventBridge rule with rate(5 minutes).
+// Scheduled EventBridge events use the CloudWatch Events envelope.
+func handler(ctx context.Context, event events.CloudWatchEvent) error {
+	logger := slog.Default()
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		logger = logger.With("awsRequestId", lc.AwsRequestID)
+	}
+	ctx = logging.NewContext(ctx, logger)
+
+	result, err := processor.Run(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "reminders failed", "error", err)
+		return err
+	}
+
+	logger.InfoContext(ctx, "reminders done", "sent", result.Sent, "failed", result.Failed)
+	return nil
+}
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("config", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logging.New(os.Stdout, cfg.LogLevel))
+	client, err := notes.NewClient(context.Background(), cfg.Store())
+	if err != nil {
+		slog.Error("dynamodb client", "error", err)
+		os.Exit(1)
+	}
+	processor = reminders.New(notes.NewStore(client, cfg.TableName))
+
+	lambda.Start(handler)
+}
@@ -2,15 +2,29 @@ package main
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/KyleJonesNV/go-service-notes/pkg/config"
 	"github.com/KyleJonesNV/go-service-notes/pkg/handlers"
+	"github.com/KyleJonesNV/go-service-notes/pkg/logging"
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
 	ginadapter "github.com/awslabs/aws-lambda-go-api-proxy/gin"
 	"github.com/gin-gonic/gin"
-	// swagger embed files
-	// gin-swagger middleware
+	"github.com/gofrs/uuid"
 )
 
 var (
@@ -18,66 +32,512 @@ var (
 )
 
 func init() {
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("config", "error", err)
+		os.Exit(1)
+	}
 	// stdout and stderr are sent to AWS CloudWatch Logs
-	log.Printf("Gin cold start")
-	r := gin.Default()
+	slog.SetDefault(logging.New(os.Stdout, cfg.LogLevel))
+	slog.Info("cold start")
+	client, err := notes.NewClient(context.Background(), cfg.Store())
+	if err != nil {
+		slog.Error("dynamodb client", "error", err)
+		os.Exit(1)
+	}
+	api := &handlers.API{Store: notes.NewStore(client, cfg.TableName)}
+
+	ginLambda = ginadapter.New(newRouter(cfg, api))
+}
+
+// newRouter registers the API routes. Endpoint groups can be switched off
+// with feature toggles.
+func newRouter(cfg *config.Config, api *handlers.API) *gin.Engine {
+	if cfg.LogLevel == config.LogDebug {
+		gin.SetMode(gin.DebugMode)
+	} else {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	r := gin.New()
+	r.Use(requestLog(slog.Default()), gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		logging.FromContext(c.Request.Context()).ErrorContext(c.Request.Context(), "panic", "panic", recovered, "stack", string(debug.Stack()))
+		abortWithResponse(c, handlers.Response{
+			StatusCode: http.StatusInternalServerError,
+			Body:       handlers.ErrorBody{ErrorMsg: "internal error", Code: handlers.CodeInternal},
+		})
+	}))
+	r.Use(cors(cfg), limitBody(cfg))
+
+	r.NoRoute(func(c *gin.Context) {
+		writeResponse(c, handlers.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       handlers.ErrorBody{ErrorMsg: fmt.Sprintf("no route %s %s", c.Request.Method, c.Request.URL.Path), Code: handlers.CodeNotFound},
+		})
+	})
 
 	r.GET("/ping", func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
 		c.JSON(200, gin.H{
 			"message": "healthy",
 		})
-	})	
+	})
+
+	// The document describes the routes registered below, so it is built
+	// once they all are.
+	var document []byte
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", document)
+	})
+
+	r.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", handlers.DocsPage)
+	})
 
 	r.POST("/getAllForUser", func(c *gin.Context) {
-		resp := handlers.GetAllForUser(c.Request)
-		c.Header("Access-Control-Allow-Origin", "*")		     
-		c.JSON(resp.StatusCode, gin.H{
-			"body": resp.Body,
-		})
-	})	
+		resp := api.GetAllForUser(c.Request)
+		writeResponse(c, resp)
+	})
 
 	r.POST("/insertTopic", func(c *gin.Context) {
-		resp := handlers.InsertTopic(c.Request)
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.JSON(resp.StatusCode, gin.H{
-			"body": resp.Body,
-		})
-	})	
+		resp := api.InsertTopic(c.Request)
+		writeResponse(c, resp)
+	})
 
 	r.DELETE("/deleteTopic", func(c *gin.Context) {
-		resp := handlers.DeleteTopic(c.Request)
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.JSON(resp.StatusCode, gin.H{
-			"body": resp.Body,
-		})
-	})	
+		resp := api.DeleteTopic(c.Request)
+		writeResponse(c, resp)
+	})
 
 	r.POST("/insertNote", func(c *gin.Context) {
-		resp := handlers.InsertNote(c.Request)
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.JSON(resp.StatusCode, gin.H{
-			"body": resp.Body,
-		})
+		resp := api.InsertNote(c.Request)
+		writeResponse(c, resp)
 	})
 
 	r.POST("/getAllNotes", func(c *gin.Context) {
-		resp := handlers.GetAllNotes(c.Request)
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.JSON(resp.StatusCode, gin.H{
-			"body": resp.Body,
-		})
+		resp := api.GetAllNotes(c.Request)
+		writeResponse(c, resp)
 	})
 
 	r.POST("/deleteNote", func(c *gin.Context) {
-		resp := handlers.DeleteNote(c.Request)
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.JSON(resp.StatusCode, gin.H{
-			"body": resp.Body,
+		resp := api.DeleteNote(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/moveTopic", func(c *gin.Context) {
+		resp := api.MoveTopic(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/moveNote", func(c *gin.Context) {
+		resp := api.MoveNote(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/pinTopic", func(c *gin.Context) {
+		resp := api.PinTopic(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/unpinTopic", func(c *gin.Context) {
+		resp := api.UnpinTopic(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/favoriteTopic", func(c *gin.Context) {
+		resp := api.FavoriteTopic(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/unfavoriteTopic", func(c *gin.Context) {
+		resp := api.UnfavoriteTopic(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/pinNote", func(c *gin.Context) {
+		resp := api.PinNote(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/unpinNote", func(c *gin.Context) {
+		resp := api.UnpinNote(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/favoriteNote", func(c *gin.Context) {
+		resp := api.FavoriteNote(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/unfavoriteNote", func(c *gin.Context) {
+		resp := api.UnfavoriteNote(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/getFavorites", func(c *gin.Context) {
+		resp := api.GetFavorites(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/getArchivedForUser", func(c *gin.Context) {
+		resp := api.GetArchivedForUser(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/archiveTopic", func(c *gin.Context) {
+		resp := api.ArchiveTopic(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/unarchiveTopic", func(c *gin.Context) {
+		resp := api.UnarchiveTopic(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/renameNote", func(c *gin.Context) {
+		resp := api.RenameNote(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/getBacklinks", func(c *gin.Context) {
+		resp := api.GetBacklinks(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/getBrokenLinks", func(c *gin.Context) {
+		resp := api.GetBrokenLinks(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/getTemplates", func(c *gin.Context) {
+		resp := api.GetTemplates(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/insertTemplate", func(c *gin.Context) {
+		resp := api.InsertTemplate(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/updateTemplate", func(c *gin.Context) {
+		resp := api.UpdateTemplate(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.DELETE("/deleteTemplate", func(c *gin.Context) {
+		resp := api.DeleteTemplate(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/setJournal", func(c *gin.Context) {
+		resp := api.SetJournal(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/getJournalEntry", func(c *gin.Context) {
+		resp := api.GetJournalEntry(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/getJournalMonth", func(c *gin.Context) {
+		resp := api.GetJournalMonth(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/getPreviousJournalEntry", func(c *gin.Context) {
+		resp := api.GetPreviousJournalEntry(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/getNextJournalEntry", func(c *gin.Context) {
+		resp := api.GetNextJournalEntry(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/getTasks", func(c *gin.Context) {
+		resp := api.GetTasks(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/setTask", func(c *gin.Context) {
+		resp := api.SetTask(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/setNoteDue", func(c *gin.Context) {
+		resp := api.SetNoteDue(c.Request)
+		writeResponse(c, resp)
+	})
+
+	r.POST("/getDueNotes", func(c *gin.Context) {
+		resp := api.GetDueNotes(c.Request)
+		writeResponse(c, resp)
+	})
+
+	if cfg.Features.Calendar {
+		r.POST("/createCalendarToken", func(c *gin.Context) {
+			resp := api.CreateCalendarToken(c.Request)
+			writeResponse(c, resp)
 		})
-	})	
 
-	ginLambda = ginadapter.New(r)
+		r.GET("/calendar.ics", func(c *gin.Context) {
+			resp := api.CalendarFeed(c.Request)
+			writeResponse(c, resp)
+		})
+	}
+
+	if cfg.Features.Imports {
+		r.POST("/importMarkdown", func(c *gin.Context) {
+			resp := api.ImportMarkdown(c.Request)
+			writeResponse(c, resp)
+		})
+
+		r.POST("/importEvernote", func(c *gin.Context) {
+			resp := api.ImportEvernote(c.Request)
+			writeResponse(c, resp)
+		})
+
+		r.POST("/importNotion", func(c *gin.Context) {
+			resp := api.ImportNotion(c.Request)
+			writeResponse(c, resp)
+		})
+	}
+
+	if cfg.Features.Exports {
+		r.POST("/exportMarkdown", func(c *gin.Context) {
+			resp := api.ExportMarkdown(c.Request)
+			writeStream(c, resp)
+		})
+
+		r.POST("/exportBackup", func(c *gin.Context) {
+			resp := api.ExportBackup(c.Request)
+			writeStream(c, resp)
+		})
+
+		r.POST("/restoreBackup", func(c *gin.Context) {
+			resp := api.RestoreBackup(c.Request)
+			writeResponse(c, resp)
+		})
+	}
+
+	document = apiDocument(r)
+	return r
+}
+
+// apiDocument returns the OpenAPI document of the routes registered on r.
+func apiDocument(r *gin.Engine) []byte {
+	var ops []handlers.Operation
+	for _, route := range r.Routes() {
+		op, ok := handlers.LookupOperation(route.Method, route.Path)
+		if !ok {
+			slog.Warn("openapi, no description", "method", route.Method, "path", route.Path)
+			continue
+		}
+		ops = append(ops, op)
+	}
+
+	document, err := json.Marshal(handlers.OpenAPI(ops))
+	if err != nil {
+		panic(fmt.Sprintf("openapi, %s", err))
+	}
+	return document
+}
+
+// exposedHeaders are the response headers scripts on other origins may read.
+var exposedHeaders = strings.Join([]string{"Content-Disposition", "X-Request-ID", "X-Total-Count"}, ", ")
+
+// cors adds the CORS headers for the origins in the configuration and answers
+// preflight requests, which browsers send before cross-origin requests that
+// are not simple, such as DELETE or a JSON body.
+func cors(cfg *config.Config) gin.HandlerFunc {
+	methods := strings.Join(cfg.CORS.Methods, ", ")
+	headers := strings.Join(cfg.CORS.Headers, ", ")
+	maxAge := strconv.Itoa(int(cfg.CORS.MaxAge.Seconds()))
+	anyOrigin := cfg.CORS.AnyOrigin() && !cfg.CORS.Credentials
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !anyOrigin {
+			header.Add("Vary", "Origin")
+		}
+		allowed := origin != "" && cfg.CORS.AllowsOrigin(origin)
+		if allowed {
+			if anyOrigin {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.CORS.Credentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if !preflight {
+			if allowed {
+				header.Set("Access-Control-Expose-Headers", exposedHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		if allowed {
+			header.Set("Access-Control-Allow-Methods", methods)
+			header.Set("Access-Control-Allow-Headers", headers)
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// limitBody rejects request bodies over the configured size. Bodies without
+// a length are cut off while the handler reads them.
+func limitBody(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > cfg.MaxBodyBytes {
+			abortWithResponse(c, handlers.PayloadTooLarge(cfg.MaxBodyBytes))
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.MaxBodyBytes)
+		c.Next()
+	}
+}
+
+// writeResponse sends a handler response. Notes are rendered as Markdown or
+// HTML for clients that prefer those. Everything else is JSON in a
+// handlers.Envelope, or the bare resource for ?envelope=false. Errors are
+// sent as RFC 7807 problem details instead to clients that ask for
+// application/problem+json.
+func writeResponse(c *gin.Context, resp handlers.Response) {
+	accept := c.GetHeader("Accept")
+	c.Writer.Header().Add("Vary", "Accept")
+
+	if errorBody, ok := resp.Body.(handlers.ErrorBody); ok {
+		logError(c, resp.StatusCode, errorBody)
+	}
+	if errorBody, ok := resp.Body.(handlers.ErrorBody); ok && handlers.WantsProblem(accept) {
+		c.Header("Content-Type", handlers.ProblemContentType)
+		c.JSON(resp.StatusCode, errorBody.Problem(resp.StatusCode, c.Request.URL.Path))
+		return
+	}
+
+	resp = handlers.Represent(resp, accept)
+	if raw, ok := resp.Body.(handlers.Raw); ok {
+		c.Data(resp.StatusCode, raw.ContentType, raw.Data)
+		return
+	}
+
+	if handlers.WantsEnvelope(c.Request) {
+		c.JSON(resp.StatusCode, handlers.Envelop(resp, requestID(c)))
+		return
+	}
+
+	if page, ok := resp.Body.(handlers.Page); ok {
+		c.Header("X-Total-Count", strconv.Itoa(page.Pagination.Total))
+	}
+	body := handlers.Unwrap(resp.Body)
+	if body == nil {
+		c.Status(http.StatusNoContent)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.JSON(resp.StatusCode, body)
+}
+
+// requestIDKey holds the request ID in the gin context.
+const requestIDKey = "requestId"
+
+// requestIDPattern is what a request ID from a client may look like.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestID identifies the request in responses, as assigned by requestLog.
+func requestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// newRequestID picks the ID of a request: the X-Request-ID header when the
+// client sends a usable one, then the API Gateway request ID, and a new one
+// otherwise.
+func newRequestID(c *gin.Context) string {
+	if id := c.GetHeader("X-Request-ID"); requestIDPattern.MatchString(id) {
+		return id
+	}
+	if gateway, ok := core.GetAPIGatewayContextFromContext(c.Request.Context()); ok && gateway.RequestID != "" {
+		return gateway.RequestID
+	}
+	return uuid.Must(uuid.NewV4()).String()
+}
+
+// requestLog assigns each request its ID, echoed in X-Request-ID, and a
+// logger carrying it through the request context. Once the request is
+// handled it logs the outcome: errors at error level, the rest at info.
+func requestLog(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := newRequestID(c)
+		c.Set(requestIDKey, id)
+		c.Header("X-Request-ID", id)
+
+		requestLogger := logger.With(requestIDKey, id)
+		if lc, ok := core.GetRuntimeContextFromContext(c.Request.Context()); ok && lc.AwsRequestID != "" {
+			requestLogger = requestLogger.With("awsRequestId", lc.AwsRequestID)
+		}
+		ctx := logging.NewContext(c.Request.Context(), requestLogger)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logging.FromContext(ctx).Log(ctx, level, "request",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency", time.Since(start),
+			"bytes", c.Writer.Size(),
+		)
+	}
+}
+
+// logError adds an error response to the request log. The causes of
+// internal errors are only logged, as they are not meant for clients.
+func logError(c *gin.Context, status int, errorBody handlers.ErrorBody) {
+	attrs := []any{"code", errorBody.Code}
+	if errorBody.Err != nil {
+		attrs = append(attrs, "error", errorBody.Err.Error())
+	} else if status >= http.StatusInternalServerError {
+		attrs = append(attrs, "error", errorBody.ErrorMsg)
+	}
+	logging.AddAttrs(c.Request.Context(), attrs...)
+}
+
+// abortWithResponse sends resp from middleware and skips the handlers.
+func abortWithResponse(c *gin.Context, resp handlers.Response) {
+	writeResponse(c, resp)
+	c.Abort()
+}
+
+// writeStream sends a handlers.Stream body as a file download. Other bodies,
+// such as errors, are sent like everywhere else.
+func writeStream(c *gin.Context, resp handlers.Response) {
+	stream, ok := resp.Body.(handlers.Stream)
+	if !ok {
+		writeResponse(c, resp)
+		return
+	}
+
+	c.Header("Content-Type", stream.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": stream.Filename}))
+	c.Status(resp.StatusCode)
+	if err := stream.Write(c.Writer); err != nil {
+		// The status line has been sent, all we can do is log.
+		logging.FromContext(c.Request.Context()).ErrorContext(c.Request.Context(), "stream failed", "error", err)
+	}
 }
 
 func handler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
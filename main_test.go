@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/config"
+	"github.com/KyleJonesNV/go-service-notes/pkg/handlers"
+	"github.com/KyleJonesNV/go-service-notes/pkg/logging"
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPI_MatchesRoutes(t *testing.T) {
+	all := config.Default()
+	none := config.Default()
+	none.Features = config.Features{}
+
+	for name, cfg := range map[string]config.Config{"all features": all, "no features": none} {
+		cfg := cfg
+		r := newRouter(&cfg, &handlers.API{})
+
+		routes := []string{}
+		for _, route := range r.Routes() {
+			routes = append(routes, route.Method+" "+route.Path)
+		}
+		sort.Strings(routes)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+		require.Equal(t, http.StatusOK, w.Code, name)
+
+		var document struct {
+			OpenAPI string                                `json:"openapi"`
+			Paths   map[string]map[string]json.RawMessage `json:"paths"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &document), name)
+		assert.Equal(t, "3.1.0", document.OpenAPI)
+
+		documented := []string{}
+		for path, item := range document.Paths {
+			for method := range item {
+				documented = append(documented, strings.ToUpper(method)+" "+path)
+			}
+		}
+		sort.Strings(documented)
+
+		assert.Equal(t, routes, documented, name)
+	}
+}
+
+func TestOpenAPI_DescribesOnlyRoutes(t *testing.T) {
+	cfg := config.Default()
+	r := newRouter(&cfg, &handlers.API{})
+
+	registered := map[string]bool{}
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for _, op := range handlers.Operations {
+		assert.True(t, registered[op.Method+" "+op.Path], "%s %s is described but not routed", op.Method, op.Path)
+	}
+}
+
+func TestDocs(t *testing.T) {
+	cfg := config.Default()
+	w := httptest.NewRecorder()
+	newRouter(&cfg, &handlers.API{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "openapi.json")
+}
+
+func TestWriteResponse(t *testing.T) {
+	write := func(target, accept string, resp handlers.Response) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, target, nil)
+		c.Request.Header.Set("Accept", accept)
+		writeResponse(c, resp)
+		return w
+	}
+	topics := []notes.Topic{{Title: "Work"}, {Title: "Home"}}
+
+	w := write("/insertTopic", "", handlers.Response{StatusCode: http.StatusOK})
+	assert.JSONEq(t, `{"meta": {}}`, w.Body.String())
+
+	w = write("/getAllForUser?limit=1", "", handlers.Response{StatusCode: http.StatusOK, Body: paged(topics, 1)})
+	var envelope struct {
+		Data []notes.Topic `json:"data"`
+		Meta handlers.Meta `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+	assert.Equal(t, "Work", envelope.Data[0].Title)
+	assert.Equal(t, 2, envelope.Meta.Pagination.Total)
+	assert.Contains(t, w.Header().Values("Vary"), "Accept")
+
+	w = write("/getAllForUser?envelope=false", "", handlers.Response{StatusCode: http.StatusOK, Body: paged(topics, 1)})
+	assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope.Data))
+	assert.Len(t, envelope.Data, 1)
+
+	w = write("/insertTopic?envelope=false", "", handlers.Response{StatusCode: http.StatusOK})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	errorBody := handlers.ErrorBody{ErrorMsg: "topic not found", Code: "topic_not_found"}
+	w = write("/getAllNotes", "", handlers.Response{StatusCode: http.StatusNotFound, Body: errorBody})
+	assert.JSONEq(t, `{"error": {"error": "topic not found", "code": "topic_not_found"}, "meta": {}}`, w.Body.String())
+
+	w = write("/getAllNotes?envelope=false", "", handlers.Response{StatusCode: http.StatusNotFound, Body: errorBody})
+	assert.JSONEq(t, `{"error": "topic not found", "code": "topic_not_found"}`, w.Body.String())
+
+	w = write("/getAllNotes", handlers.ProblemContentType, handlers.Response{StatusCode: http.StatusNotFound, Body: errorBody})
+	assert.Equal(t, handlers.ProblemContentType, w.Header().Get("Content-Type"))
+
+	w = write("/getAllNotes", "text/markdown", handlers.Response{StatusCode: http.StatusOK, Body: &topics[0]})
+	assert.Equal(t, handlers.MarkdownContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "# Work\n\n", w.Body.String())
+}
+
+func paged(topics []notes.Topic, limit int) handlers.Page {
+	next := limit
+	return handlers.Page{
+		Items:      topics[:limit],
+		Pagination: handlers.Pagination{Limit: limit, Total: len(topics), NextOffset: &next},
+	}
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	cfg := config.Default()
+	cfg.CORS.Origins = []string{"https://app.example.com"}
+	cfg.CORS.Credentials = true
+	r := newRouter(&cfg, &handlers.API{})
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/deleteTopic", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+		req.Header.Set("Access-Control-Request-Headers", "content-type")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := preflight("https://app.example.com")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "GET, POST, DELETE", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Accept, Content-Type, X-Request-ID", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, w.Header().Values("Vary"), "Origin")
+
+	w = preflight("https://evil.example.com")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestCORS_Requests(t *testing.T) {
+	cfg := config.Default()
+	cfg.MaxBodyBytes = 4
+	r := newRouter(&cfg, &handlers.API{})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "X-Total-Count")
+
+	// Errors raised by middleware are readable too.
+	req = httptest.NewRequest(http.MethodPost, "/insertTopic", strings.NewReader(`{"userId": "x"}`))
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+
+	req = httptest.NewRequest(http.MethodGet, "/ping", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestRequestID(t *testing.T) {
+	cfg := config.Default()
+	r := newRouter(&cfg, &handlers.API{})
+
+	req := httptest.NewRequest(http.MethodPost, "/insertTopic", strings.NewReader(`{}`))
+	req.Header.Set("X-Request-ID", "client-1234")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "client-1234", w.Header().Get("X-Request-ID"))
+	var envelope handlers.Envelope
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+	assert.Equal(t, "client-1234", envelope.Meta.RequestID)
+
+	// IDs that are not safe to log are replaced.
+	req = httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("X-Request-ID", "bad id\n")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	id := w.Header().Get("X-Request-ID")
+	assert.NotEmpty(t, id)
+	assert.NotEqual(t, "bad id\n", id)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	assert.NotEmpty(t, w.Header().Get("X-Request-ID"))
+	assert.NotEqual(t, id, w.Header().Get("X-Request-ID"))
+}
+
+func TestRequestLog(t *testing.T) {
+	var buf bytes.Buffer
+	r := gin.New()
+	r.Use(requestLog(logging.New(&buf, "info")))
+	r.GET("/fail", func(c *gin.Context) {
+		logging.AddAttrs(c.Request.Context(), "user", "user-1")
+		writeResponse(c, handlers.Response{StatusCode: http.StatusInternalServerError, Body: handlers.ErrorBody{Code: "internal", ErrorMsg: "internal error", Err: errors.New("table missing")}})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "ERROR", line["level"])
+	assert.Equal(t, "request", line["msg"])
+	assert.Equal(t, "req-1", line["requestId"])
+	assert.Equal(t, "user-1", line["user"])
+	assert.Equal(t, "internal", line["code"])
+	assert.Equal(t, "table missing", line["error"])
+	assert.Equal(t, "/fail", line["path"])
+	assert.EqualValues(t, http.StatusInternalServerError, line["status"])
+	assert.Contains(t, line, "latency")
+}
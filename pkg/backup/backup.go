@@ -0,0 +1,433 @@
+// Package backup saves a user's account as a single JSON document and
+// restores it, either merged into the current account or replacing it.
+//
+// The document is versioned. Fields may be added to a version, so readers
+// ignore fields they do not know; anything else bumps Version.
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+// Version is the backup format written by Create.
+const Version = 1
+
+var (
+	// ErrUnsupportedVersion is returned for backups without a version or
+	// written by a newer format.
+	ErrUnsupportedVersion = &notes.Error{Kind: notes.ErrValidation, Message: "unsupported backup version"}
+	// ErrInvalidBackup is returned for backups that cannot be restored as
+	// they are, such as ones with duplicate titles.
+	ErrInvalidBackup = &notes.Error{Kind: notes.ErrValidation, Message: "invalid backup"}
+	// ErrUnknownMode is returned by Restore for modes other than Merge and
+	// Replace.
+	ErrUnknownMode = &notes.Error{Kind: notes.ErrValidation, Message: "unknown restore mode"}
+)
+
+// Backup is the whole of a user's notes.
+type Backup struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	User      User      `json:"user"`
+	Topics    []Topic   `json:"topics"`
+}
+
+type User struct {
+	ID      string `json:"id"`
+	Email   string `json:"email"`
+	Name    string `json:"name,omitempty"`
+	Surname string `json:"surname,omitempty"`
+}
+
+type Topic struct {
+	Title        string     `json:"title"`
+	Position     string     `json:"position,omitempty"`
+	Pinned       bool       `json:"pinned,omitempty"`
+	Favorite     bool       `json:"favorite,omitempty"`
+	Archived     bool       `json:"archived,omitempty"`
+	ArchivedAt   *time.Time `json:"archivedAt,omitempty"`
+	JournalDates []string   `json:"journalDates,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	Notes        []Note     `json:"notes"`
+}
+
+type Note struct {
+	ID         string     `json:"id,omitempty"`
+	Title      string     `json:"title"`
+	Content    string     `json:"content"`
+	Position   string     `json:"position,omitempty"`
+	Pinned     bool       `json:"pinned,omitempty"`
+	Favorite   bool       `json:"favorite,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	DueAt      *time.Time `json:"dueAt,omitempty"`
+	RemindAt   *time.Time `json:"remindAt,omitempty"`
+	RemindedAt *time.Time `json:"remindedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// Restore modes.
+const (
+	// Merge adds what is missing from the account and keeps everything
+	// already there. Notes that exist with different content are reported as
+	// conflicts and left as they are.
+	Merge = "merge"
+	// Replace makes the account's topics exactly those of the backup,
+	// deleting topics the backup does not have.
+	Replace = "replace"
+)
+
+// Conflict is something in the backup that Restore did not apply.
+type Conflict struct {
+	Topic  string `json:"topic,omitempty"`
+	Note   string `json:"note,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// RestoreResult summarises a restore.
+type RestoreResult struct {
+	Mode string `json:"mode"`
+	// Topics are the topics written, whole or in part.
+	Topics []string `json:"topics"`
+	// Notes is the number of notes added or replaced.
+	Notes int `json:"notes"`
+	// Unchanged is the number of notes that already matched the backup.
+	Unchanged int        `json:"unchanged"`
+	Deleted   []string   `json:"deleted"`
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// Store reads and writes a user's account.
+type Store interface {
+	GetUserByID(ctx context.Context, userID string) (*notes.User, error)
+	GetAllTopicsForUser(ctx context.Context, userID string) ([]notes.Topic, error)
+	RestoreTopic(ctx context.Context, userID string, topic notes.Topic) error
+	DeleteTopic(ctx context.Context, userID, title string) error
+}
+
+// Service creates and restores backups against a Store.
+type Service struct {
+	Store Store
+	Now   func() time.Time
+}
+
+// New returns a service backed by the notes table.
+func New(store *notes.Store) *Service {
+	return &Service{
+		Store: store,
+		Now:   func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Create returns a backup of the user's account, archived topics included.
+func (s *Service) Create(ctx context.Context, userID string) (*Backup, error) {
+	user, err := s.Store.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user by id, %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("unknown user %q", userID)
+	}
+
+	topics, err := s.Store.GetAllTopicsForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get all topics for user, %w", err)
+	}
+
+	b := &Backup{
+		Version:   Version,
+		CreatedAt: s.Now(),
+		User: User{
+			ID:      user.ID,
+			Email:   user.Email,
+			Name:    user.Name,
+			Surname: user.Surname,
+		},
+		Topics: make([]Topic, 0, len(topics)),
+	}
+	for _, topic := range topics {
+		b.Topics = append(b.Topics, fromTopic(topic))
+	}
+
+	return b, nil
+}
+
+// Decode reads a backup and checks that it can be restored.
+func Decode(r io.Reader) (*Backup, error) {
+	var b Backup
+	err := json.NewDecoder(r).Decode(&b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBackup, err)
+	}
+
+	err = b.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// Validate checks the version and that titles are present and unique.
+func (b *Backup) Validate() error {
+	if b.Version < 1 || b.Version > Version {
+		return fmt.Errorf("%w %d, expected %d", ErrUnsupportedVersion, b.Version, Version)
+	}
+
+	topics := map[string]bool{}
+	for _, topic := range b.Topics {
+		if topic.Title == "" {
+			return fmt.Errorf("%w: topic without a title", ErrInvalidBackup)
+		}
+		if topics[topic.Title] {
+			return fmt.Errorf("%w: topic %q appears twice", ErrInvalidBackup, topic.Title)
+		}
+		topics[topic.Title] = true
+
+		titles := map[string]bool{}
+		for _, note := range topic.Notes {
+			if note.Title == "" {
+				return fmt.Errorf("%w: note without a title in topic %q", ErrInvalidBackup, topic.Title)
+			}
+			if titles[note.Title] {
+				return fmt.Errorf("%w: note %q appears twice in topic %q", ErrInvalidBackup, note.Title, topic.Title)
+			}
+			titles[note.Title] = true
+		}
+	}
+
+	return nil
+}
+
+// Restore applies a backup to the user's account in the given mode. The
+// backup may come from another account; that is reported as a conflict but
+// does not stop the restore.
+func (s *Service) Restore(ctx context.Context, userID string, b *Backup, mode string) (RestoreResult, error) {
+	result := RestoreResult{
+		Mode:      mode,
+		Topics:    []string{},
+		Deleted:   []string{},
+		Conflicts: []Conflict{},
+	}
+
+	if mode != Merge && mode != Replace {
+		return result, fmt.Errorf("%w %q", ErrUnknownMode, mode)
+	}
+	err := b.Validate()
+	if err != nil {
+		return result, err
+	}
+
+	user, err := s.Store.GetUserByID(ctx, userID)
+	if err != nil {
+		return result, fmt.Errorf("get user by id, %w", err)
+	}
+	if user == nil {
+		return result, fmt.Errorf("unknown user %q", userID)
+	}
+	if b.User.ID != "" && b.User.ID != user.ID {
+		result.Conflicts = append(result.Conflicts, Conflict{
+			Reason: fmt.Sprintf("backup was made from account %q", b.User.Email),
+		})
+	}
+
+	topics, err := s.Store.GetAllTopicsForUser(ctx, userID)
+	if err != nil {
+		return result, fmt.Errorf("get all topics for user, %w", err)
+	}
+	existing := map[string]notes.Topic{}
+	for _, topic := range topics {
+		existing[topic.Title] = topic
+	}
+
+	backedUp := map[string]bool{}
+	for _, topic := range b.Topics {
+		backedUp[topic.Title] = true
+
+		current, ok := existing[topic.Title]
+		restored := toTopic(topic)
+		if mode == Merge && ok {
+			var changed bool
+			restored, changed, err = merge(current, topic, &result)
+			if err != nil {
+				return result, err
+			}
+			if !changed {
+				continue
+			}
+		} else {
+			result.Notes += len(restored.Notes)
+		}
+
+		err = s.Store.RestoreTopic(ctx, userID, restored)
+		if err != nil {
+			return result, fmt.Errorf("restore topic %q, %w", topic.Title, err)
+		}
+		result.Topics = append(result.Topics, topic.Title)
+	}
+
+	if mode == Replace {
+		for _, topic := range topics {
+			if backedUp[topic.Title] {
+				continue
+			}
+			err = s.Store.DeleteTopic(ctx, userID, topic.Title)
+			if err != nil {
+				return result, fmt.Errorf("delete topic %q, %w", topic.Title, err)
+			}
+			result.Deleted = append(result.Deleted, topic.Title)
+		}
+	}
+
+	return result, nil
+}
+
+// merge adds the backed up notes missing from current. A backed up note is
+// matched by ID first, so a note renamed since the backup is not added twice,
+// then by title. Notes present in both are kept as they are, and reported as
+// a conflict when they differ. The journal dates of both are kept.
+func merge(current notes.Topic, topic Topic, result *RestoreResult) (notes.Topic, bool, error) {
+	changed := false
+	for _, backedUp := range topic.Notes {
+		note := toNote(backedUp)
+
+		if existing := matchNote(current.Notes, note); existing != nil {
+			if existing.Content == note.Content {
+				result.Unchanged++
+				continue
+			}
+			reason := "note exists with different content, kept the current one"
+			if existing.Title != note.Title {
+				reason = fmt.Sprintf("note was renamed to %q and has different content, kept the current one", existing.Title)
+			} else if note.UpdatedAt.After(existing.UpdatedAt) {
+				reason = "note exists with different content and the backup is newer, kept the current one"
+			}
+			result.Conflicts = append(result.Conflicts, Conflict{Topic: topic.Title, Note: note.Title, Reason: reason})
+			continue
+		}
+
+		position, err := notes.PositionBetween(lastPosition(current.Notes), "")
+		if err != nil {
+			return current, false, fmt.Errorf("position between, %w", err)
+		}
+		note.Position = position
+		current.Notes = append(current.Notes, note)
+		result.Notes++
+		changed = true
+	}
+
+	for _, date := range topic.JournalDates {
+		if !containsString(current.JournalDates, date) {
+			current.JournalDates = append(current.JournalDates, date)
+			changed = true
+		}
+	}
+
+	return current, changed, nil
+}
+
+// matchNote finds the current note a backed up note corresponds to, by ID or
+// else by title.
+func matchNote(list []notes.Note, note notes.Note) *notes.Note {
+	if note.ID != "" {
+		for i := range list {
+			if list[i].ID == note.ID {
+				return &list[i]
+			}
+		}
+	}
+	return notes.FindNote(list, note.Title)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func lastPosition(notes []notes.Note) string {
+	last := ""
+	for _, note := range notes {
+		if note.Position > last {
+			last = note.Position
+		}
+	}
+	return last
+}
+
+func fromTopic(topic notes.Topic) Topic {
+	t := Topic{
+		Title:        topic.Title,
+		Position:     topic.Position,
+		Pinned:       topic.Pinned,
+		Favorite:     topic.Favorite,
+		Archived:     topic.Archived,
+		ArchivedAt:   topic.ArchivedAt,
+		JournalDates: topic.JournalDates,
+		CreatedAt:    topic.CreatedAt,
+		UpdatedAt:    topic.UpdatedAt,
+		Notes:        make([]Note, 0, len(topic.Notes)),
+	}
+	for _, note := range topic.Notes {
+		t.Notes = append(t.Notes, Note{
+			ID:         note.ID,
+			Title:      note.Title,
+			Content:    note.Content,
+			Position:   note.Position,
+			Pinned:     note.Pinned,
+			Favorite:   note.Favorite,
+			Tags:       note.Tags,
+			DueAt:      note.DueAt,
+			RemindAt:   note.RemindAt,
+			RemindedAt: note.RemindedAt,
+			CreatedAt:  note.CreatedAt,
+			UpdatedAt:  note.UpdatedAt,
+		})
+	}
+	return t
+}
+
+func toTopic(topic Topic) notes.Topic {
+	t := notes.Topic{
+		Title:        topic.Title,
+		Position:     topic.Position,
+		Pinned:       topic.Pinned,
+		Favorite:     topic.Favorite,
+		Archived:     topic.Archived,
+		ArchivedAt:   topic.ArchivedAt,
+		JournalDates: topic.JournalDates,
+		CreatedAt:    topic.CreatedAt,
+		UpdatedAt:    topic.UpdatedAt,
+	}
+	for _, note := range topic.Notes {
+		t.Notes = append(t.Notes, toNote(note))
+	}
+	return t
+}
+
+func toNote(note Note) notes.Note {
+	return notes.Note{
+		ID:         note.ID,
+		Title:      note.Title,
+		Content:    note.Content,
+		Position:   note.Position,
+		Pinned:     note.Pinned,
+		Favorite:   note.Favorite,
+		Tags:       note.Tags,
+		DueAt:      note.DueAt,
+		RemindAt:   note.RemindAt,
+		RemindedAt: note.RemindedAt,
+		CreatedAt:  note.CreatedAt,
+		UpdatedAt:  note.UpdatedAt,
+	}
+}
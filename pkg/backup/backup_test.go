@@ -0,0 +1,205 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	t1 = time.Date(2023, 4, 1, 9, 0, 0, 0, time.UTC)
+	t2 = time.Date(2023, 4, 2, 9, 0, 0, 0, time.UTC)
+)
+
+type fakeStore struct {
+	user     *notes.User
+	topics   []notes.Topic
+	restored []notes.Topic
+	deleted  []string
+}
+
+func (s *fakeStore) GetUserByID(ctx context.Context, userID string) (*notes.User, error) {
+	return s.user, nil
+}
+
+func (s *fakeStore) GetAllTopicsForUser(ctx context.Context, userID string) ([]notes.Topic, error) {
+	return s.topics, nil
+}
+
+func (s *fakeStore) RestoreTopic(ctx context.Context, userID string, topic notes.Topic) error {
+	s.restored = append(s.restored, topic)
+	return nil
+}
+
+func (s *fakeStore) DeleteTopic(ctx context.Context, userID, title string) error {
+	s.deleted = append(s.deleted, title)
+	return nil
+}
+
+func newStore() *fakeStore {
+	return &fakeStore{
+		user: &notes.User{ID: "u1", Email: "a@example.com", Name: "A"},
+		topics: []notes.Topic{
+			{Title: "Work", Position: "V", CreatedAt: t1, UpdatedAt: t1, Notes: []notes.Note{
+				{Title: "Same", Content: "same", Position: "V", CreatedAt: t1, UpdatedAt: t1},
+				{Title: "Edited", Content: "mine", Position: "k", CreatedAt: t1, UpdatedAt: t1},
+			}},
+			{Title: "Only here", CreatedAt: t1, UpdatedAt: t1},
+		},
+	}
+}
+
+func testBackup() *Backup {
+	return &Backup{
+		Version: Version,
+		User:    User{ID: "u1", Email: "a@example.com"},
+		Topics: []Topic{
+			{Title: "Work", Notes: []Note{
+				{Title: "Same", Content: "same", CreatedAt: t1, UpdatedAt: t1},
+				{Title: "Edited", Content: "theirs", CreatedAt: t1, UpdatedAt: t2},
+				{Title: "New", Content: "new", Tags: []string{"x"}, CreatedAt: t2, UpdatedAt: t2},
+			}},
+			{Title: "Ideas", Position: "V", Archived: true, CreatedAt: t1, UpdatedAt: t2, Notes: []Note{
+				{Title: "Idea", Content: "idea", CreatedAt: t1, UpdatedAt: t1},
+			}},
+		},
+	}
+}
+
+func TestCreate_RoundTrip(t *testing.T) {
+	store := newStore()
+	service := &Service{Store: store, Now: func() time.Time { return t2 }}
+
+	b, err := service.Create(context.Background(), "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, Version, b.Version)
+	assert.Equal(t, t2, b.CreatedAt)
+	assert.Equal(t, "a@example.com", b.User.Email)
+	assert.Len(t, b.Topics, 2)
+
+	var buf bytes.Buffer
+	assert.NoError(t, json.NewEncoder(&buf).Encode(b))
+
+	decoded, err := Decode(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, b, decoded)
+	assert.Equal(t, store.topics[0], toTopic(decoded.Topics[0]))
+}
+
+func TestDecode_Errors(t *testing.T) {
+	_, err := Decode(strings.NewReader(`{"topics": []}`))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	_, err = Decode(strings.NewReader(`{"version": 2}`))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	_, err = Decode(strings.NewReader(`{"version": 1, "topics": [{"title": "a"}, {"title": "a"}]}`))
+	assert.ErrorIs(t, err, ErrInvalidBackup)
+
+	_, err = Decode(strings.NewReader(`{"version": 1, "topics": [{"title": "a", "notes": [{"title": ""}]}]}`))
+	assert.ErrorIs(t, err, ErrInvalidBackup)
+
+	_, err = Decode(strings.NewReader(`not json`))
+	assert.ErrorIs(t, err, ErrInvalidBackup)
+}
+
+func TestRestore_Merge(t *testing.T) {
+	store := newStore()
+	service := &Service{Store: store}
+
+	result, err := service.Restore(context.Background(), "u1", testBackup(), Merge)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"Work", "Ideas"}, result.Topics)
+	assert.Equal(t, 2, result.Notes)
+	assert.Equal(t, 1, result.Unchanged)
+	assert.Empty(t, result.Deleted)
+	assert.Empty(t, store.deleted)
+	assert.Len(t, result.Conflicts, 1)
+	assert.Equal(t, "Edited", result.Conflicts[0].Note)
+	assert.Contains(t, result.Conflicts[0].Reason, "backup is newer")
+
+	work := store.restored[0]
+	assert.Len(t, work.Notes, 3)
+	assert.Equal(t, "mine", work.Notes[1].Content)
+	assert.Equal(t, "New", work.Notes[2].Title)
+	assert.Greater(t, work.Notes[2].Position, "k")
+
+	ideas := store.restored[1]
+	assert.True(t, ideas.Archived)
+	assert.Equal(t, t2, ideas.UpdatedAt)
+}
+
+func TestRestore_MergeRenamedNote(t *testing.T) {
+	store := newStore()
+	store.topics[0].Notes[0].ID = "n1"
+	store.topics[0].Notes[0].Title = "Same, renamed"
+	store.topics[0].JournalDates = []string{"2023-04-01"}
+	service := &Service{Store: store}
+
+	b := testBackup()
+	b.Topics = b.Topics[:1]
+	b.Topics[0].Notes = []Note{
+		{ID: "n1", Title: "Same", Content: "same", CreatedAt: t1, UpdatedAt: t1},
+		{Title: "2023-04-02", Content: "journal", CreatedAt: t2, UpdatedAt: t2},
+	}
+	b.Topics[0].JournalDates = []string{"2023-04-01", "2023-04-02"}
+
+	result, err := service.Restore(context.Background(), "u1", b, Merge)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Unchanged)
+	assert.Equal(t, 1, result.Notes)
+
+	work := store.restored[0]
+	assert.Len(t, work.Notes, 3)
+	assert.Equal(t, "Same, renamed", work.Notes[0].Title)
+	assert.Equal(t, "2023-04-02", work.Notes[2].Title)
+	assert.Equal(t, []string{"2023-04-01", "2023-04-02"}, work.JournalDates)
+}
+
+func TestRestore_MergeNothingNew(t *testing.T) {
+	store := newStore()
+	service := &Service{Store: store}
+
+	b := testBackup()
+	b.Topics = b.Topics[:1]
+	b.Topics[0].Notes = b.Topics[0].Notes[:1]
+
+	result, err := service.Restore(context.Background(), "u1", b, Merge)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Topics)
+	assert.Empty(t, store.restored)
+	assert.Equal(t, 1, result.Unchanged)
+}
+
+func TestRestore_Replace(t *testing.T) {
+	store := newStore()
+	service := &Service{Store: store}
+
+	b := testBackup()
+	b.User = User{ID: "other", Email: "b@example.com"}
+
+	result, err := service.Restore(context.Background(), "u1", b, Replace)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"Work", "Ideas"}, result.Topics)
+	assert.Equal(t, 4, result.Notes)
+	assert.Equal(t, []string{"Only here"}, result.Deleted)
+	assert.Equal(t, []string{"Only here"}, store.deleted)
+	assert.Equal(t, "theirs", store.restored[0].Notes[1].Content)
+	assert.Len(t, result.Conflicts, 1)
+	assert.Contains(t, result.Conflicts[0].Reason, "b@example.com")
+}
+
+func TestRestore_UnknownMode(t *testing.T) {
+	service := &Service{Store: newStore()}
+
+	_, err := service.Restore(context.Background(), "u1", testBackup(), "overwrite")
+	assert.ErrorIs(t, err, ErrUnknownMode)
+}
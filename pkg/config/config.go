@@ -0,0 +1,322 @@
+// Package config loads the service configuration. Defaults are overridden by
+// an optional YAML (or JSON) file named by NOTES_CONFIG_FILE, which is in turn
+// overridden by environment variables. The result is validated as a whole, so
+// every problem is reported at startup.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables read by Load.
+const (
+	EnvConfigFile   = "NOTES_CONFIG_FILE"
+	EnvTableName    = "NOTES_TABLE_NAME"
+	EnvRegion       = "NOTES_REGION"
+	EnvEndpoint     = "NOTES_DYNAMODB_ENDPOINT"
+	EnvLogLevel     = "NOTES_LOG_LEVEL"
+	EnvMaxBodyBytes = "NOTES_MAX_BODY_BYTES"
+
+	EnvCORSOrigins     = "NOTES_CORS_ORIGINS"
+	EnvCORSMethods     = "NOTES_CORS_METHODS"
+	EnvCORSHeaders     = "NOTES_CORS_HEADERS"
+	EnvCORSCredentials = "NOTES_CORS_CREDENTIALS"
+	EnvCORSMaxAge      = "NOTES_CORS_MAX_AGE"
+
+	// EnvFeaturePrefix followed by a feature name in upper case, e.g.
+	// NOTES_FEATURE_CALENDAR=false, toggles that feature.
+	EnvFeaturePrefix = "NOTES_FEATURE_"
+)
+
+// Log levels.
+const (
+	LogDebug = "debug"
+	LogInfo  = "info"
+	LogWarn  = "warn"
+	LogError = "error"
+)
+
+// DefaultMaxBodyBytes matches the payload limit of API Gateway.
+const DefaultMaxBodyBytes = 10 << 20
+
+// Config is the whole service configuration.
+type Config struct {
+	// TableName is the DynamoDB table.
+	TableName string `yaml:"tableName"`
+	// Region overrides the AWS region of the environment.
+	Region string `yaml:"region"`
+	// Endpoint overrides the DynamoDB endpoint, e.g. for DynamoDB Local.
+	Endpoint string `yaml:"endpoint"`
+	CORS     CORS   `yaml:"cors"`
+	LogLevel string `yaml:"logLevel"`
+	// MaxBodyBytes limits the size of request bodies. Imports carry whole
+	// archives, so it is generous by default.
+	MaxBodyBytes int64    `yaml:"maxBodyBytes"`
+	Features     Features `yaml:"features"`
+}
+
+// CORS controls which browser pages may call the API.
+type CORS struct {
+	// Origins are the origins browsers may call the API from. "*" allows
+	// any origin.
+	Origins []string `yaml:"origins"`
+	// Methods and Headers are what a cross-origin request may use beyond
+	// the basics browsers always allow.
+	Methods []string `yaml:"methods"`
+	Headers []string `yaml:"headers"`
+	// Credentials lets browsers send cookies and authorization headers.
+	// It needs the origins listed explicitly.
+	Credentials bool `yaml:"credentials"`
+	// MaxAge is how long browsers may cache the answer to a preflight
+	// request.
+	MaxAge time.Duration `yaml:"maxAge"`
+}
+
+// Features turns optional groups of endpoints on or off. All are on by
+// default.
+type Features struct {
+	// Calendar is the iCalendar feed and its tokens.
+	Calendar bool `yaml:"calendar"`
+	// Imports are the Markdown, Evernote and Notion imports.
+	Imports bool `yaml:"imports"`
+	// Exports are the Markdown export and the account backup and restore.
+	Exports bool `yaml:"exports"`
+}
+
+// Default returns the configuration used when nothing is set.
+func Default() Config {
+	return Config{
+		TableName: notes.DefaultTableName,
+		CORS: CORS{
+			Origins: []string{"*"},
+			Methods: []string{http.MethodGet, http.MethodPost, http.MethodDelete},
+			Headers: []string{"Accept", "Content-Type", "X-Request-ID"},
+			MaxAge:  10 * time.Minute,
+		},
+		LogLevel:     LogInfo,
+		MaxBodyBytes: DefaultMaxBodyBytes,
+		Features: Features{
+			Calendar: true,
+			Imports:  true,
+			Exports:  true,
+		},
+	}
+}
+
+// Load reads the configuration from the process environment.
+func Load() (*Config, error) {
+	return LoadFrom(os.LookupEnv)
+}
+
+// LoadFrom reads the configuration using lookup for environment variables.
+func LoadFrom(lookup func(key string) (string, bool)) (*Config, error) {
+	cfg := Default()
+
+	if file, ok := lookup(EnvConfigFile); ok && file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("config file, %w", err)
+		}
+		err = yaml.Unmarshal(data, &cfg)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s, %w", file, err)
+		}
+	}
+
+	err := applyEnv(&cfg, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func applyEnv(cfg *Config, lookup func(key string) (string, bool)) error {
+	if value, ok := lookup(EnvTableName); ok {
+		cfg.TableName = value
+	}
+	if value, ok := lookup(EnvRegion); ok {
+		cfg.Region = value
+	}
+	if value, ok := lookup(EnvEndpoint); ok {
+		cfg.Endpoint = value
+	}
+	if value, ok := lookup(EnvCORSOrigins); ok {
+		cfg.CORS.Origins = splitList(value)
+	}
+	if value, ok := lookup(EnvCORSMethods); ok {
+		cfg.CORS.Methods = splitList(value)
+	}
+	if value, ok := lookup(EnvCORSHeaders); ok {
+		cfg.CORS.Headers = splitList(value)
+	}
+	if value, ok := lookup(EnvLogLevel); ok {
+		cfg.LogLevel = value
+	}
+
+	var errs []error
+	if value, ok := lookup(EnvMaxBodyBytes); ok {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %q is not a number", EnvMaxBodyBytes, value))
+		} else {
+			cfg.MaxBodyBytes = size
+		}
+	}
+	if value, ok := lookup(EnvCORSCredentials); ok {
+		credentials, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %q is not a boolean", EnvCORSCredentials, value))
+		} else {
+			cfg.CORS.Credentials = credentials
+		}
+	}
+	if value, ok := lookup(EnvCORSMaxAge); ok {
+		maxAge, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %q is not a duration", EnvCORSMaxAge, value))
+		} else {
+			cfg.CORS.MaxAge = maxAge
+		}
+	}
+	for name, feature := range cfg.Features.byName() {
+		value, ok := lookup(EnvFeaturePrefix + strings.ToUpper(name))
+		if !ok {
+			continue
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s%s: %q is not a boolean", EnvFeaturePrefix, strings.ToUpper(name), value))
+			continue
+		}
+		*feature = enabled
+	}
+
+	return errors.Join(errs...)
+}
+
+func (f *Features) byName() map[string]*bool {
+	return map[string]*bool{
+		"calendar": &f.Calendar,
+		"imports":  &f.Imports,
+		"exports":  &f.Exports,
+	}
+}
+
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,255}$`)
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if !tableNamePattern.MatchString(c.TableName) {
+		errs = append(errs, fmt.Errorf("tableName %q: must be 3 to 255 letters, digits, '_', '-' or '.'", c.TableName))
+	}
+	if c.Endpoint != "" {
+		u, err := url.Parse(c.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("endpoint %q: must be an http or https URL", c.Endpoint))
+		}
+	}
+	errs = append(errs, c.CORS.validate()...)
+	if c.MaxBodyBytes <= 0 {
+		errs = append(errs, fmt.Errorf("maxBodyBytes %d: must be positive", c.MaxBodyBytes))
+	}
+	switch c.LogLevel {
+	case LogDebug, LogInfo, LogWarn, LogError:
+	default:
+		errs = append(errs, fmt.Errorf("logLevel %q: must be one of debug, info, warn, error", c.LogLevel))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Store returns the settings for the notes package.
+func (c *Config) Store() notes.Options {
+	return notes.Options{
+		TableName: c.TableName,
+		Region:    c.Region,
+		Endpoint:  c.Endpoint,
+	}
+}
+
+// AllowsOrigin reports whether a browser on origin may call the API.
+func (c CORS) AllowsOrigin(origin string) bool {
+	for _, allowed := range c.Origins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnyOrigin reports whether every origin is allowed.
+func (c CORS) AnyOrigin() bool {
+	for _, allowed := range c.Origins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+var tokenPattern = regexp.MustCompile(`^[!#$%&'*+.^_|~0-9A-Za-z-]+$`)
+
+func (c CORS) validate() []error {
+	var errs []error
+	for _, origin := range c.Origins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			errs = append(errs, fmt.Errorf("cors.origins %q: must be \"*\" or scheme://host[:port]", origin))
+		}
+	}
+	for _, method := range c.Methods {
+		if !tokenPattern.MatchString(method) || method != strings.ToUpper(method) {
+			errs = append(errs, fmt.Errorf("cors.methods %q: must be an upper case HTTP method", method))
+		}
+	}
+	for _, header := range c.Headers {
+		if !tokenPattern.MatchString(header) {
+			errs = append(errs, fmt.Errorf("cors.headers %q: must be a header name", header))
+		}
+	}
+	if c.Credentials && c.AnyOrigin() {
+		errs = append(errs, errors.New("cors.credentials: needs the origins listed, not \"*\""))
+	}
+	if c.MaxAge < 0 {
+		errs = append(errs, fmt.Errorf("cors.maxAge %s: must not be negative", c.MaxAge))
+	}
+	return errs
+}
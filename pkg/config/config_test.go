@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"github.com/stretchr/testify/assert"
+)
+
+func env(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := LoadFrom(env(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, Default(), *cfg)
+	assert.Equal(t, notes.Options{TableName: notes.DefaultTableName}, cfg.Store())
+}
+
+func TestLoad_FileAndEnv(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(file, []byte("tableName: notes-staging\n"+
+		"region: eu-west-1\n"+
+		"cors:\n"+
+		"  origins: [https://app.example.com]\n"+
+		"  credentials: true\n"+
+		"  maxAge: 1h\n"+
+		"features:\n"+
+		"  imports: false\n"), 0o600)
+	assert.NoError(t, err)
+
+	cfg, err := LoadFrom(env(map[string]string{
+		EnvConfigFile:           file,
+		EnvRegion:               "eu-central-1",
+		EnvEndpoint:             "http://localhost:8000",
+		EnvLogLevel:             "debug",
+		EnvMaxBodyBytes:         "1024",
+		EnvCORSHeaders:          "Content-Type, Authorization",
+		"NOTES_FEATURE_EXPORTS": "false",
+	}))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "notes-staging", cfg.TableName)
+	assert.Equal(t, "eu-central-1", cfg.Region)
+	assert.Equal(t, "http://localhost:8000", cfg.Endpoint)
+	assert.Equal(t, CORS{
+		Origins:     []string{"https://app.example.com"},
+		Methods:     []string{"GET", "POST", "DELETE"},
+		Headers:     []string{"Content-Type", "Authorization"},
+		Credentials: true,
+		MaxAge:      time.Hour,
+	}, cfg.CORS)
+	assert.Equal(t, LogDebug, cfg.LogLevel)
+	assert.Equal(t, int64(1024), cfg.MaxBodyBytes)
+	assert.Equal(t, Features{Calendar: true, Imports: false, Exports: false}, cfg.Features)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	_, err := LoadFrom(env(map[string]string{
+		EnvTableName:             "x",
+		EnvEndpoint:              "localhost:8000",
+		EnvCORSOrigins:           "*, https://ok.example.com, ftp://bad, https://bad.example.com/path",
+		EnvLogLevel:              "verbose",
+		EnvCORSMaxAge:            "600",
+		"NOTES_FEATURE_CALENDAR": "maybe",
+	}))
+	assert.ErrorContains(t, err, "NOTES_FEATURE_CALENDAR")
+	assert.ErrorContains(t, err, EnvCORSMaxAge)
+
+	_, err = LoadFrom(env(map[string]string{
+		EnvTableName:    "x",
+		EnvEndpoint:     "localhost:8000",
+		EnvCORSOrigins:  "*, https://ok.example.com, ftp://bad, https://bad.example.com/path",
+		EnvLogLevel:     "verbose",
+		EnvMaxBodyBytes: "0",
+		EnvCORSMethods:  "GET, patch, BAD METHOD",
+		EnvCORSHeaders:  "Content-Type, X:Bad",
+	}))
+	assert.ErrorContains(t, err, "tableName")
+	assert.ErrorContains(t, err, "endpoint")
+	assert.ErrorContains(t, err, "ftp://bad")
+	assert.ErrorContains(t, err, "https://bad.example.com/path")
+	assert.NotContains(t, err.Error(), "ok.example.com")
+	assert.ErrorContains(t, err, "logLevel")
+	assert.ErrorContains(t, err, "maxBodyBytes")
+	assert.ErrorContains(t, err, `"patch"`)
+	assert.ErrorContains(t, err, "BAD METHOD")
+	assert.ErrorContains(t, err, "X:Bad")
+	assert.NotContains(t, err.Error(), `"GET"`)
+
+	_, err = LoadFrom(env(map[string]string{EnvCORSCredentials: "true"}))
+	assert.ErrorContains(t, err, "cors.credentials")
+
+	_, err = LoadFrom(env(map[string]string{EnvConfigFile: filepath.Join(t.TempDir(), "missing.yaml")}))
+	assert.ErrorContains(t, err, "config file")
+}
+
+func TestAllowsOrigin(t *testing.T) {
+	cors := Default().CORS
+	assert.True(t, cors.AllowsOrigin("https://anything.example.com"))
+	assert.True(t, cors.AnyOrigin())
+
+	cors.Origins = []string{"https://app.example.com/"}
+	assert.True(t, cors.AllowsOrigin("https://app.example.com"))
+	assert.False(t, cors.AllowsOrigin("https://evil.example.com"))
+	assert.False(t, cors.AnyOrigin())
+}
@@ -0,0 +1,261 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+var page = template.Must(template.New("page").Funcs(template.FuncMap{
+	"anchor": Anchor,
+	"markdown": func(content string) template.HTML {
+		return template.HTML(RenderMarkdown(content))
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+{{- range .Topics}}
+<section{{with .Title}} id="{{anchor .}}"{{end}}>
+{{- with .Title}}
+<h1>{{.}}</h1>
+{{- end}}
+{{- range .Notes}}
+<article id="{{anchor .Title}}">
+<h2>{{.Title}}</h2>
+{{markdown .Content}}
+</article>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+// HTML writes topics as one standalone HTML page, a section per topic and an
+// article per note. Topics without a title only group their notes.
+func HTML(w io.Writer, title string, topics []notes.Topic) error {
+	err := page.Execute(w, struct {
+		Title  string
+		Topics []notes.Topic
+	}{title, topics})
+	if err != nil {
+		return fmt.Errorf("render page, %w", err)
+	}
+	return nil
+}
+
+// Document writes topics as one Markdown document, a level one heading per
+// topic and a level two heading per note followed by its content. Topics
+// without a title only group their notes.
+func Document(w io.Writer, topics []notes.Topic) error {
+	var buf bytes.Buffer
+	for _, topic := range topics {
+		if topic.Title != "" {
+			fmt.Fprintf(&buf, "# %s\n\n", topic.Title)
+		}
+		for _, note := range topic.Notes {
+			fmt.Fprintf(&buf, "## %s\n\n", note.Title)
+			if content := strings.TrimSpace(note.Content); content != "" {
+				buf.WriteString(content)
+				buf.WriteString("\n\n")
+			}
+		}
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// Anchor turns a title into the id of its element in HTML output, which wiki
+// links point at.
+func Anchor(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	itemPattern    = regexp.MustCompile(`^\s*(?:([-*+])|\d+[.)])\s+(.*)$`)
+	checkPattern   = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	rulePattern    = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+
+	wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+)(?:\|([^\[\]\n]*))?\]\]`)
+	linkPattern     = regexp.MustCompile(`\[([^\[\]]+)\]\(([^()\s]+)\)`)
+	strongPattern   = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*`)
+	emphasisPattern = regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*`)
+)
+
+// RenderMarkdown converts the Markdown of a note to HTML. It covers what
+// notes commonly use: headings, paragraphs, lists and checklists, quotes,
+// fenced code, rules, inline code, emphasis, links and [[wiki links]], which
+// point at the anchor of their note. Raw HTML in the content is escaped and
+// links other than http, https, mailto and relative ones are dropped.
+func RenderMarkdown(content string) string {
+	var b strings.Builder
+	var paragraph, quote []string
+	list := ""
+	fence := ""
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			fmt.Fprintf(&b, "<p>%s</p>\n", inline(strings.Join(paragraph, "\n")))
+			paragraph = nil
+		}
+		if len(quote) > 0 {
+			fmt.Fprintf(&b, "<blockquote><p>%s</p></blockquote>\n", inline(strings.Join(quote, "\n")))
+			quote = nil
+		}
+		if list != "" {
+			fmt.Fprintf(&b, "</%s>\n", list)
+			list = ""
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				b.WriteString("</code></pre>\n")
+				fence = ""
+				continue
+			}
+			b.WriteString(html.EscapeString(line))
+			b.WriteString("\n")
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			fence = trimmed[:3]
+			if language := strings.TrimSpace(trimmed[3:]); language != "" {
+				fmt.Fprintf(&b, `<pre><code class="language-%s">`, html.EscapeString(language))
+			} else {
+				b.WriteString("<pre><code>")
+			}
+			continue
+		}
+
+		switch match := itemPattern.FindStringSubmatch(line); {
+		case trimmed == "":
+			flush()
+		case rulePattern.MatchString(line):
+			flush()
+			b.WriteString("<hr>\n")
+		case headingPattern.MatchString(trimmed):
+			flush()
+			heading := headingPattern.FindStringSubmatch(trimmed)
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", len(heading[1]), inline(heading[2]), len(heading[1]))
+		case strings.HasPrefix(trimmed, ">"):
+			if len(paragraph) > 0 || list != "" {
+				flush()
+			}
+			quote = append(quote, strings.TrimSpace(strings.TrimPrefix(trimmed, ">")))
+		case match != nil:
+			kind := "ol"
+			if match[1] != "" {
+				kind = "ul"
+			}
+			if list != kind {
+				flush()
+				fmt.Fprintf(&b, "<%s>\n", kind)
+				list = kind
+			}
+			item := match[2]
+			if check := checkPattern.FindStringSubmatch(item); check != nil {
+				checked := ""
+				if check[1] != " " {
+					checked = " checked"
+				}
+				fmt.Fprintf(&b, `<li><input type="checkbox" disabled%s> %s</li>`+"\n", checked, inline(check[2]))
+			} else {
+				fmt.Fprintf(&b, "<li>%s</li>\n", inline(item))
+			}
+		default:
+			if list != "" || len(quote) > 0 {
+				flush()
+			}
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+
+	if fence != "" {
+		b.WriteString("</code></pre>\n")
+	}
+	flush()
+	return b.String()
+}
+
+// inline renders the spans of one block. Code spans are taken out first so
+// nothing inside them is interpreted.
+func inline(text string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(text, "`") {
+		if i%2 == 1 {
+			fmt.Fprintf(&b, "<code>%s</code>", html.EscapeString(part))
+			continue
+		}
+		b.WriteString(spans(html.EscapeString(part)))
+	}
+	return b.String()
+}
+
+// spans renders links and emphasis in text that is already escaped.
+func spans(text string) string {
+	text = wikiLinkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := wikiLinkPattern.FindStringSubmatch(match)
+		target := strings.TrimSpace(parts[1])
+		label := strings.TrimSpace(parts[2])
+		if label == "" {
+			label = target
+		}
+		if _, note, ok := strings.Cut(target, "/"); ok {
+			target = note
+		}
+		return fmt.Sprintf(`<a class="wiki-link" href="#%s">%s</a>`, Anchor(html.UnescapeString(target)), label)
+	})
+	text = linkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := linkPattern.FindStringSubmatch(match)
+		if !safeURL(html.UnescapeString(parts[2])) {
+			return parts[1]
+		}
+		return fmt.Sprintf(`<a href="%s">%s</a>`, parts[2], parts[1])
+	})
+	text = strongPattern.ReplaceAllString(text, "<strong>$1</strong>")
+	return emphasisPattern.ReplaceAllString(text, "<em>$1</em>")
+}
+
+// safeURL reports whether a link target can be followed without running
+// anything, e.g. no javascript: URLs.
+func safeURL(url string) bool {
+	scheme, _, ok := strings.Cut(url, ":")
+	if !ok || strings.ContainsAny(scheme, "/?#") {
+		return true
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
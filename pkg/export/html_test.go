@@ -0,0 +1,82 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	content := "# Plan\n" +
+		"Some **bold** and *soft* text\nwith `a <b>` span.\n" +
+		"\n" +
+		"- [ ] write\n" +
+		"- [x] test\n" +
+		"1. one\n" +
+		"\n" +
+		"> quoted\n" +
+		"---\n" +
+		"```go\nif a < b {}\n```\n" +
+		"See [[Work/Road Map|the map]], [site](https://example.com) and [bad](javascript:alert%281%29).\n" +
+		"<script>alert(1)</script>"
+
+	assert.Equal(t, "<h1>Plan</h1>\n"+
+		"<p>Some <strong>bold</strong> and <em>soft</em> text\nwith <code>a &lt;b&gt;</code> span.</p>\n"+
+		"<ul>\n"+
+		`<li><input type="checkbox" disabled> write</li>`+"\n"+
+		`<li><input type="checkbox" disabled checked> test</li>`+"\n"+
+		"</ul>\n"+
+		"<ol>\n<li>one</li>\n</ol>\n"+
+		"<blockquote><p>quoted</p></blockquote>\n"+
+		"<hr>\n"+
+		`<pre><code class="language-go">if a &lt; b {}`+"\n</code></pre>\n"+
+		`<p>See <a class="wiki-link" href="#road-map">the map</a>, <a href="https://example.com">site</a> and bad.`+"\n"+
+		"&lt;script&gt;alert(1)&lt;/script&gt;</p>\n",
+		RenderMarkdown(content))
+}
+
+func TestSafeURL(t *testing.T) {
+	for url, want := range map[string]bool{
+		"https://example.com": true,
+		"mailto:a@b.c":        true,
+		"/relative/path":      true,
+		"page#x:y":            true,
+		"javascript:alert(1)": false,
+		"JavaScript:x":        false,
+		"data:text/html,x":    false,
+	} {
+		assert.Equal(t, want, safeURL(url), url)
+	}
+}
+
+func TestAnchor(t *testing.T) {
+	assert.Equal(t, "road-map-2024", Anchor("  Road Map: 2024!"))
+	assert.Equal(t, "übersicht", Anchor("Übersicht"))
+}
+
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, HTML(&buf, "Work & Play", []notes.Topic{{
+		Title: "Work",
+		Notes: []notes.Note{{Title: "Road Map", Content: "Ship <it>"}},
+	}}))
+
+	page := buf.String()
+	assert.Contains(t, page, "<title>Work &amp; Play</title>")
+	assert.Contains(t, page, `<section id="work">`)
+	assert.Contains(t, page, `<article id="road-map">`)
+	assert.Contains(t, page, "<p>Ship &lt;it&gt;</p>")
+}
+
+func TestDocument(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Document(&buf, []notes.Topic{
+		{Title: "Work", Notes: []notes.Note{{Title: "Road Map", Content: "Ship it\n"}, {Title: "Empty"}}},
+		{Notes: []notes.Note{{Title: "2024-05-01", Content: "Journal"}}},
+	}))
+
+	assert.Equal(t, "# Work\n\n## Road Map\n\nShip it\n\n## Empty\n\n## 2024-05-01\n\nJournal\n\n", buf.String())
+}
@@ -0,0 +1,166 @@
+// Package export writes a user's notes in portable formats.
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"gopkg.in/yaml.v3"
+)
+
+// maxNameBytes keeps generated names well within the 255 byte limit of common
+// file systems, leaving room for a dedup suffix and the extension.
+const maxNameBytes = 100
+
+// Frontmatter is the YAML header written at the top of each exported note.
+type Frontmatter struct {
+	Title    string     `yaml:"title"`
+	Created  time.Time  `yaml:"created"`
+	Updated  time.Time  `yaml:"updated"`
+	Tags     []string   `yaml:"tags,omitempty"`
+	Due      *time.Time `yaml:"due,omitempty"`
+	Pinned   bool       `yaml:"pinned,omitempty"`
+	Favorite bool       `yaml:"favorite,omitempty"`
+}
+
+// Markdown writes topics to w as a zip archive with one directory per topic
+// and one .md file per note. Entries are written as they are produced, so the
+// archive is never held in memory.
+func Markdown(w io.Writer, topics []notes.Topic) error {
+	archive := zip.NewWriter(w)
+
+	dirs := names{}
+	for _, topic := range topics {
+		dir := dirs.claim(SanitizeName(topic.Title))
+
+		_, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     dir + "/",
+			Modified: topic.UpdatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("create directory %q, %w", dir, err)
+		}
+
+		files := names{}
+		for _, note := range topic.Notes {
+			name := files.claim(SanitizeName(note.Title))
+
+			file, err := archive.CreateHeader(&zip.FileHeader{
+				Name:     path.Join(dir, name+".md"),
+				Method:   zip.Deflate,
+				Modified: note.UpdatedAt,
+			})
+			if err != nil {
+				return fmt.Errorf("create file %q, %w", name, err)
+			}
+
+			err = WriteNote(file, note)
+			if err != nil {
+				return fmt.Errorf("write note %q, %w", note.Title, err)
+			}
+		}
+	}
+
+	return archive.Close()
+}
+
+// WriteNote writes a note as YAML frontmatter followed by its content.
+func WriteNote(w io.Writer, note notes.Note) error {
+	header, err := yaml.Marshal(Frontmatter{
+		Title:    note.Title,
+		Created:  note.CreatedAt.UTC(),
+		Updated:  note.UpdatedAt.UTC(),
+		Tags:     note.Tags,
+		Due:      note.DueAt,
+		Pinned:   note.Pinned,
+		Favorite: note.Favorite,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal frontmatter, %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(header)
+	buf.WriteString("---\n\n")
+	buf.WriteString(note.Content)
+	if note.Content != "" && !strings.HasSuffix(note.Content, "\n") {
+		buf.WriteString("\n")
+	}
+
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// SanitizeName turns a title into a file name that is valid on Windows, macOS
+// and Linux. Path separators, reserved and control characters are replaced,
+// leading and trailing dots and spaces are trimmed and reserved device names
+// are suffixed. An empty result becomes "Untitled".
+func SanitizeName(title string) string {
+	var b strings.Builder
+	for _, r := range title {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r):
+			b.WriteRune(' ')
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			b.WriteRune('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	name := strings.Join(strings.Fields(b.String()), " ")
+	name = truncate(name, maxNameBytes)
+	name = strings.Trim(name, ". ")
+
+	if name == "" {
+		return "Untitled"
+	}
+	if reservedName(name) {
+		return name + "_"
+	}
+	return name
+}
+
+// truncate cuts s to at most n bytes without splitting a rune.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func reservedName(name string) bool {
+	base, _, _ := strings.Cut(name, ".")
+	switch strings.ToUpper(strings.TrimSpace(base)) {
+	case "CON", "PRN", "AUX", "NUL",
+		"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+		"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9":
+		return true
+	}
+	return false
+}
+
+// names hands out unique names within one directory. Names are compared
+// case-insensitively since most desktop file systems do.
+type names map[string]bool
+
+func (n names) claim(name string) string {
+	unique := name
+	for i := 2; n[strings.ToLower(unique)]; i++ {
+		unique = fmt.Sprintf("%s (%d)", name, i)
+	}
+	n[strings.ToLower(unique)] = true
+	return unique
+}
@@ -0,0 +1,86 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := map[string]string{
+		"Plain":               "Plain",
+		"a/b\\c:d":            "a-b-c-d",
+		`what? "quoted" <x>|`: `what- -quoted- -x--`,
+		"  spaced\tout\n ":    "spaced out",
+		"...hidden.":          "hidden",
+		"":                    "Untitled",
+		"///":                 "---",
+		"con":                 "con_",
+		"LPT1.txt":            "LPT1.txt_",
+		"console":             "console",
+	}
+	for title, want := range tests {
+		assert.Equal(t, want, SanitizeName(title), title)
+	}
+
+	long := SanitizeName(strings.Repeat("é", 80))
+	assert.LessOrEqual(t, len(long), maxNameBytes)
+	assert.True(t, strings.HasPrefix(strings.Repeat("é", 80), long))
+}
+
+func TestNamesClaim(t *testing.T) {
+	n := names{}
+	assert.Equal(t, "Note", n.claim("Note"))
+	assert.Equal(t, "note (2)", n.claim("note"))
+	assert.Equal(t, "Note (3)", n.claim("Note"))
+	assert.Equal(t, "Other", n.claim("Other"))
+}
+
+func TestMarkdown(t *testing.T) {
+	created := time.Date(2023, 4, 1, 9, 30, 0, 0, time.UTC)
+	updated := created.Add(48 * time.Hour)
+
+	topics := []notes.Topic{
+		{Title: "Work/Projects", Notes: []notes.Note{
+			{Title: "Plan", Content: "# Plan\n- [ ] ship", Tags: []string{"q2", "planning"}, CreatedAt: created, UpdatedAt: updated},
+			{Title: "Plan?", Content: "second", CreatedAt: created, UpdatedAt: created},
+		}},
+		{Title: "work-projects"},
+	}
+
+	var buf bytes.Buffer
+	err := Markdown(&buf, topics)
+	assert.NoError(t, err)
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	files := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		assert.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		assert.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+
+	assert.Contains(t, files, "Work-Projects/")
+	assert.Contains(t, files, "work-projects (2)/")
+	assert.Equal(t, "---\n"+
+		"title: Plan\n"+
+		"created: 2023-04-01T09:30:00Z\n"+
+		"updated: 2023-04-03T09:30:00Z\n"+
+		"tags:\n"+
+		"    - q2\n"+
+		"    - planning\n"+
+		"---\n\n"+
+		"# Plan\n- [ ] ship\n", files["Work-Projects/Plan.md"])
+	assert.Contains(t, files["Work-Projects/Plan-.md"], "title: Plan?\n")
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+func (a *API) ArchiveTopic(req *http.Request) Response {
+	return setTopicFlag(req, "archive", a.setTopicArchived, true)
+}
+
+func (a *API) UnarchiveTopic(req *http.Request) Response {
+	return setTopicFlag(req, "unarchive", a.setTopicArchived, false)
+}
+
+// GetArchivedForUser lists the topics that GetAllForUser leaves out.
+func (a *API) GetArchivedForUser(req *http.Request) Response {
+	var user = User{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &user)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	page, pageViolations := pageRequest(req)
+	if violations := append(Validate(user), pageViolations...); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, user.ID)
+
+	topics, err := a.Store.GetArchivedForUser(req.Context(), user.ID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return Response{http.StatusOK, paginate(topics, page)}
+}
+
+func (a *API) setTopicArchived(ctx context.Context, userID, title string, archived bool) error {
+	if archived {
+		return a.Store.ArchiveTopic(ctx, userID, title)
+	}
+	return a.Store.UnarchiveTopic(ctx, userID, title)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/backup"
+)
+
+type RestoreBackupRequest struct {
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+	// Mode is "merge" or "replace".
+	Mode   string          `json:"mode,omitempty" validate:"required,oneof=merge|replace"`
+	Backup json.RawMessage `json:"backup,omitempty" validate:"required"`
+}
+
+// ExportBackup returns the user's whole account as a versioned JSON backup.
+func (a *API) ExportBackup(req *http.Request) Response {
+	var exportRequest = ExportRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &exportRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(exportRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, exportRequest.UserID)
+
+	b, err := backup.New(a.Store).Create(req.Context(), exportRequest.UserID)
+	if err != nil {
+		return errorResponse(fmt.Errorf("backup, %w", err))
+	}
+
+	return Response{http.StatusOK, Stream{
+		ContentType: "application/json",
+		Filename:    "notes-backup.json",
+		Write: func(w io.Writer) error {
+			out := json.NewEncoder(w)
+			out.SetIndent("", "  ")
+			return out.Encode(b)
+		},
+	}}
+}
+
+// RestoreBackup restores a backup made by ExportBackup into the user's
+// account.
+func (a *API) RestoreBackup(req *http.Request) Response {
+	var restoreBackupRequest = RestoreBackupRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &restoreBackupRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(restoreBackupRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, restoreBackupRequest.UserID)
+
+	b, err := backup.Decode(bytes.NewReader(restoreBackupRequest.Backup))
+	if err != nil {
+		return errorResponse(fmt.Errorf("restore, %w", err))
+	}
+
+	result, err := backup.New(a.Store).Restore(req.Context(), restoreBackupRequest.UserID, b, restoreBackupRequest.Mode)
+	if err != nil {
+		return errorResponse(fmt.Errorf("restore, %w", err))
+	}
+
+	return Response{http.StatusOK, result}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/ical"
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+// Raw is a Response body that is written as-is rather than encoded as JSON.
+type Raw struct {
+	ContentType string
+	Data        []byte
+}
+
+type CreateCalendarTokenRequest struct {
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+}
+
+type CalendarTokenResponse struct {
+	Token string `json:"token,omitempty"`
+	// Path is the feed path to subscribe to, relative to the API root.
+	Path string `json:"path,omitempty"`
+}
+
+// CreateCalendarToken issues a new calendar feed token for the user. Any
+// previous token stops working.
+func (a *API) CreateCalendarToken(req *http.Request) Response {
+	var createCalendarTokenRequest = CreateCalendarTokenRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &createCalendarTokenRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(createCalendarTokenRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, createCalendarTokenRequest.UserID)
+
+	token, err := a.Store.CreateCalendarToken(req.Context(), createCalendarTokenRequest.UserID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{http.StatusOK, CalendarTokenResponse{
+		Token: token.Token,
+		Path:  "/calendar.ics?token=" + url.QueryEscape(token.Token),
+	}}
+}
+
+// CalendarFeed renders the notes with due dates of the user owning the token
+// query parameter as an iCalendar document.
+func (a *API) CalendarFeed(req *http.Request) Response {
+	userID, err := a.Store.GetCalendarUser(req.Context(), req.URL.Query().Get("token"))
+	if errors.Is(err, notes.ErrCalendarTokenNotFound) {
+		return Response{http.StatusNotFound, ErrorBody{ErrorMsg: ErrIDNotFound, Code: CodeNotFound}}
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+	logUser(req, userID)
+
+	topics, err := a.Store.GetAllForUser(req.Context(), userID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	var buf bytes.Buffer
+	_, err = ical.FromNotes(userID, topics).WriteTo(&buf)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{http.StatusOK, Raw{
+		ContentType: "text/calendar; charset=utf-8",
+		Data:        buf.Bytes(),
+	}}
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/export"
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+// Representations of notes besides JSON.
+const (
+	MarkdownContentType = "text/markdown; charset=utf-8"
+	HTMLContentType     = "text/html; charset=utf-8"
+)
+
+// MaxPageLimit is the largest page of a list a client can ask for.
+const MaxPageLimit = 1000
+
+// Envelope is the JSON body of a response. Exactly one of Data and Error is
+// set, Data is left out when a request succeeds without a result.
+type Envelope struct {
+	Data  any        `json:"data,omitempty"`
+	Error *ErrorBody `json:"error,omitempty"`
+	Meta  Meta       `json:"meta"`
+}
+
+// Meta describes the response rather than the resource.
+type Meta struct {
+	RequestID  string      `json:"requestId,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination locates a page within its list.
+type Pagination struct {
+	Offset int `json:"offset"`
+	// Limit is the page size asked for, 0 for the whole list.
+	Limit int `json:"limit,omitempty"`
+	Total int `json:"total"`
+	// NextOffset is the offset of the following page, if there is one.
+	NextOffset *int `json:"nextOffset,omitempty"`
+}
+
+// Page is a Response body holding part of a list.
+type Page struct {
+	Items      any
+	Pagination Pagination
+}
+
+// PageRequest selects part of a list with the limit and offset query
+// parameters. Without a limit the whole list is returned.
+type PageRequest struct {
+	Limit  int
+	Offset int
+}
+
+// pageRequest reads the page of a list endpoint from the query string.
+func pageRequest(req *http.Request) (PageRequest, []FieldError) {
+	page := PageRequest{}
+	violations := []FieldError{}
+	query := req.URL.Query()
+	for _, param := range []struct {
+		name  string
+		value *int
+		max   int
+	}{
+		{"limit", &page.Limit, MaxPageLimit},
+		{"offset", &page.Offset, 0},
+	} {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		switch {
+		case err != nil || n < 0:
+			violations = append(violations, FieldError{Field: param.name, Message: "must be a whole number"})
+		case param.max > 0 && n > param.max:
+			violations = append(violations, FieldError{Field: param.name, Message: fmt.Sprintf("must be at most %d", param.max)})
+		default:
+			*param.value = n
+		}
+	}
+	return page, violations
+}
+
+// paginate cuts the requested page out of items.
+func paginate[T any](items []T, page PageRequest) Page {
+	total := len(items)
+	start := page.Offset
+	if start > total {
+		start = total
+	}
+	end := total
+	if page.Limit > 0 && start+page.Limit < total {
+		end = start + page.Limit
+	}
+
+	pagination := Pagination{Offset: page.Offset, Limit: page.Limit, Total: total}
+	if end < total {
+		pagination.NextOffset = &end
+	}
+	result := make([]T, end-start)
+	copy(result, items[start:end])
+	return Page{Items: result, Pagination: pagination}
+}
+
+// Envelop wraps the body of resp for sending.
+func Envelop(resp Response, requestID string) Envelope {
+	envelope := Envelope{Meta: Meta{RequestID: requestID}}
+	switch body := resp.Body.(type) {
+	case ErrorBody:
+		envelope.Error = &body
+	case Page:
+		envelope.Data = body.Items
+		envelope.Meta.Pagination = &body.Pagination
+	default:
+		envelope.Data = body
+	}
+	return envelope
+}
+
+// Unwrap returns the resource in a response body, for clients that ask for
+// it without the envelope.
+func Unwrap(body any) any {
+	if page, ok := body.(Page); ok {
+		return page.Items
+	}
+	return body
+}
+
+// WantsEnvelope reports whether a request takes the default enveloped
+// response. Clients ask for the bare resource with ?envelope=false.
+func WantsEnvelope(req *http.Request) bool {
+	wanted, err := strconv.ParseBool(req.URL.Query().Get("envelope"))
+	return err != nil || wanted
+}
+
+// acceptQualities parses an Accept header into the quality of each media
+// range it lists.
+func acceptQualities(accept string) map[string]float64 {
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+		}
+		qualities[mediaType] = q
+	}
+	return qualities
+}
+
+// Negotiate returns the offered media type the Accept header ranks highest,
+// the earliest offer on a tie. Without an Accept header that is the first
+// offer. It returns "" when none of the offers is acceptable.
+func Negotiate(accept string, offers ...string) string {
+	if strings.TrimSpace(accept) == "" && len(offers) > 0 {
+		return offers[0]
+	}
+
+	qualities := acceptQualities(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, ok := qualities[offer]
+		if !ok {
+			major, _, _ := strings.Cut(offer, "/")
+			if q, ok = qualities[major+"/*"]; !ok {
+				q = qualities["*/*"]
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// Represent renders the notes in a successful response as Markdown or HTML
+// when the Accept header prefers either to JSON. Other responses are
+// returned as they are.
+func Represent(resp Response, accept string) Response {
+	if resp.StatusCode >= 300 {
+		return resp
+	}
+	mediaType := Negotiate(accept, "application/json", "text/markdown", "text/html")
+	if mediaType != "text/markdown" && mediaType != "text/html" {
+		return resp
+	}
+	title, topics, note, ok := noteBody(Unwrap(resp.Body))
+	if !ok {
+		return resp
+	}
+
+	var buf bytes.Buffer
+	var err error
+	contentType := MarkdownContentType
+	switch {
+	case mediaType == "text/html":
+		contentType = HTMLContentType
+		err = export.HTML(&buf, title, topics)
+	case note != nil:
+		err = export.WriteNote(&buf, *note)
+	default:
+		err = export.Document(&buf, topics)
+	}
+	if err != nil {
+		return errorResponse(fmt.Errorf("render %s, %w", mediaType, err))
+	}
+	return Response{resp.StatusCode, Raw{ContentType: contentType, Data: buf.Bytes()}}
+}
+
+// noteBody recognizes the response bodies that are notes. They are returned
+// as topics, with note set when the body is a single note.
+func noteBody(body any) (title string, topics []notes.Topic, note *notes.Note, ok bool) {
+	switch body := body.(type) {
+	case notes.Note:
+		return body.Title, []notes.Topic{{Notes: []notes.Note{body}}}, &body, true
+	case *notes.Note:
+		if body == nil {
+			return "", nil, nil, false
+		}
+		return noteBody(*body)
+	case notes.Topic:
+		return body.Title, []notes.Topic{body}, nil, true
+	case *notes.Topic:
+		if body == nil {
+			return "", nil, nil, false
+		}
+		return noteBody(*body)
+	case []notes.Note:
+		return "Notes", []notes.Topic{{Notes: body}}, nil, true
+	case []notes.Topic:
+		return "Notes", body, nil, true
+	}
+	return "", nil, nil, false
+}
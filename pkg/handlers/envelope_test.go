@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginate(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+	next := func(n int) *int { return &n }
+
+	assert.Equal(t, Page{Items: items, Pagination: Pagination{Total: 5}}, paginate(items, PageRequest{}))
+	assert.Equal(t, Page{
+		Items:      []string{"c", "d"},
+		Pagination: Pagination{Offset: 2, Limit: 2, Total: 5, NextOffset: next(4)},
+	}, paginate(items, PageRequest{Limit: 2, Offset: 2}))
+	assert.Equal(t, Page{
+		Items:      []string{"e"},
+		Pagination: Pagination{Offset: 4, Limit: 2, Total: 5},
+	}, paginate(items, PageRequest{Limit: 2, Offset: 4}))
+	assert.Equal(t, Page{
+		Items:      []string{},
+		Pagination: Pagination{Offset: 9, Total: 5},
+	}, paginate(items, PageRequest{Offset: 9}))
+	assert.Equal(t, []notes.Topic{}, paginate([]notes.Topic(nil), PageRequest{}).Items)
+}
+
+func TestPageRequest(t *testing.T) {
+	page, violations := pageRequest(httptest.NewRequest(http.MethodPost, "/getTasks?limit=10&offset=20", nil))
+	assert.Equal(t, PageRequest{Limit: 10, Offset: 20}, page)
+	assert.Empty(t, violations)
+
+	_, violations = pageRequest(httptest.NewRequest(http.MethodPost, "/getTasks?limit=5000&offset=-1", nil))
+	assert.Equal(t, []FieldError{
+		{Field: "limit", Message: "must be at most 1000"},
+		{Field: "offset", Message: "must be a whole number"},
+	}, violations)
+}
+
+func TestEnvelop(t *testing.T) {
+	assert.Equal(t, Envelope{Meta: Meta{RequestID: "r1"}}, Envelop(Response{http.StatusOK, nil}, "r1"))
+	assert.Equal(t, Envelope{Data: []string{"a"}}, Envelop(Response{http.StatusOK, []string{"a"}}, ""))
+
+	errorBody := ErrorBody{ErrorMsg: "gone", Code: CodeNotFound}
+	assert.Equal(t, Envelope{Error: &errorBody}, Envelop(Response{http.StatusNotFound, errorBody}, ""))
+
+	page := paginate([]string{"a", "b"}, PageRequest{Limit: 1})
+	envelope := Envelop(Response{http.StatusOK, page}, "")
+	assert.Equal(t, []string{"a"}, envelope.Data)
+	assert.Equal(t, &page.Pagination, envelope.Meta.Pagination)
+	assert.Equal(t, []string{"a"}, Unwrap(page))
+}
+
+func TestWantsEnvelope(t *testing.T) {
+	for target, want := range map[string]bool{
+		"/getTasks":                true,
+		"/getTasks?envelope=true":  true,
+		"/getTasks?envelope=false": false,
+		"/getTasks?envelope=0":     false,
+		"/getTasks?envelope=nope":  true,
+	} {
+		assert.Equal(t, want, WantsEnvelope(httptest.NewRequest(http.MethodPost, target, nil)), target)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "text/markdown", "text/html"}
+	for accept, want := range map[string]string{
+		"":                                    "application/json",
+		"*/*":                                 "application/json",
+		"text/markdown":                       "text/markdown",
+		"text/html,application/xhtml+xml":     "text/html",
+		"text/*":                              "text/markdown",
+		"application/json;q=0.5, text/html":   "text/html",
+		"text/html;q=0.4, */*;q=0.5":          "application/json",
+		"image/png":                           "",
+		"application/problem+json":            "",
+		"text/markdown;q=0, application/json": "application/json",
+	} {
+		assert.Equal(t, want, Negotiate(accept, offers...), accept)
+	}
+}
+
+func TestRepresent(t *testing.T) {
+	note := notes.Note{Title: "Road Map", Content: "Ship **it**"}
+	topic := &notes.Topic{Title: "Work", Notes: []notes.Note{note}}
+
+	resp := Represent(Response{http.StatusOK, topic}, "text/markdown")
+	assert.Equal(t, Response{http.StatusOK, Raw{
+		ContentType: MarkdownContentType,
+		Data:        []byte("# Work\n\n## Road Map\n\nShip **it**\n\n"),
+	}}, resp)
+
+	resp = Represent(Response{http.StatusOK, &note}, "text/markdown")
+	assert.True(t, strings.HasPrefix(string(resp.Body.(Raw).Data), "---\ntitle: Road Map\n"))
+
+	resp = Represent(Response{http.StatusOK, paginate([]notes.Note{note}, PageRequest{})}, "text/html")
+	assert.Equal(t, HTMLContentType, resp.Body.(Raw).ContentType)
+	assert.Contains(t, string(resp.Body.(Raw).Data), "<p>Ship <strong>it</strong></p>")
+
+	unchanged := []Response{
+		{http.StatusOK, topic},
+		{http.StatusOK, []notes.TaskRef{}},
+		{http.StatusNotFound, ErrorBody{Code: CodeNotFound}},
+		{http.StatusOK, (*notes.Note)(nil)},
+	}
+	for _, resp := range unchanged {
+		accept := "text/html"
+		if resp.Body == topic {
+			accept = "application/json"
+		}
+		assert.Equal(t, resp, Represent(resp, accept))
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/backup"
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+// Error codes sent in ErrorBody.Code. Clients can rely on them; the messages
+// are for people and may change.
+const (
+	CodeInvalidPayload = "invalid_payload"
+	CodeValidation     = "validation_failed"
+	CodeNotFound       = "not_found"
+	CodeConflict       = "conflict"
+	CodeForbidden      = "forbidden"
+	CodeInternal       = "internal"
+)
+
+// errorKinds maps the kinds of domain errors to a status and a generic code.
+var errorKinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{notes.ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{notes.ErrConflict, http.StatusConflict, CodeConflict},
+	{notes.ErrValidation, http.StatusUnprocessableEntity, CodeValidation},
+	{notes.ErrForbidden, http.StatusForbidden, CodeForbidden},
+}
+
+// errorCodes are the codes of specific domain errors. Errors that are not
+// listed get the code of their kind.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{notes.ErrUserNotFound, "user_not_found"},
+	{notes.ErrTopicNotFound, "topic_not_found"},
+	{notes.ErrNoteNotFound, "note_not_found"},
+	{notes.ErrTemplateNotFound, "template_not_found"},
+	{notes.ErrTaskNotFound, "task_not_found"},
+	{notes.ErrJournalNotConfigured, "journal_not_configured"},
+	{notes.ErrJournalEntryMissing, "journal_entry_missing"},
+	{notes.ErrCalendarTokenNotFound, "calendar_token_not_found"},
+	{notes.ErrTopicExists, "topic_exists"},
+	{notes.ErrNoteExists, "note_exists"},
+	{notes.ErrTemplateExists, "template_exists"},
+	{notes.ErrTopicArchived, "topic_archived"},
+	{notes.ErrTaskChanged, "task_changed"},
+	{notes.ErrTopicChanged, "topic_changed"},
+	{notes.ErrMissingTemplateFields, "missing_template_fields"},
+	{backup.ErrUnsupportedVersion, "unsupported_backup_version"},
+	{backup.ErrInvalidBackup, "invalid_backup"},
+	{backup.ErrUnknownMode, "unknown_restore_mode"},
+}
+
+// errorResponse turns an error from the domain packages into a response.
+// Domain errors get the status of their kind, anything else is a 500 whose
+// message is kept from the client, as it may describe the table or AWS.
+func errorResponse(err error) Response {
+	status, code := http.StatusInternalServerError, CodeInternal
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind.kind) {
+			status, code = kind.status, kind.code
+			break
+		}
+	}
+	for _, specific := range errorCodes {
+		if errors.Is(err, specific.err) {
+			code = specific.code
+			break
+		}
+	}
+
+	if code == CodeInternal {
+		return Response{status, ErrorBody{ErrorMsg: "internal error", Code: code, Err: err}}
+	}
+	return Response{status, ErrorBody{ErrorMsg: err.Error(), Code: code}}
+}
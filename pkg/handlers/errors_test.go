@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/backup"
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorResponse(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("insert, topic %q: %w", "Ideas", notes.ErrTopicNotFound), http.StatusNotFound, "topic_not_found"},
+		{fmt.Errorf("insert, topic %q: %w", "Ideas", notes.ErrTopicExists), http.StatusConflict, "topic_exists"},
+		{fmt.Errorf("topic %q: %w", "Ideas", notes.ErrTopicArchived), http.StatusConflict, "topic_archived"},
+		{fmt.Errorf("insert, %w", notes.ErrMissingTemplateFields), http.StatusUnprocessableEntity, "missing_template_fields"},
+		{fmt.Errorf("restore, %w", backup.ErrUnknownMode), http.StatusUnprocessableEntity, "unknown_restore_mode"},
+		{&notes.Error{Kind: notes.ErrForbidden, Message: "not yours"}, http.StatusForbidden, CodeForbidden},
+		{&notes.Error{Kind: notes.ErrValidation, Message: "bad month"}, http.StatusUnprocessableEntity, CodeValidation},
+	}
+
+	for _, tt := range tests {
+		response := errorResponse(tt.err)
+		assert.Equal(t, tt.status, response.StatusCode, tt.err.Error())
+		assert.Equal(t, ErrorBody{ErrorMsg: tt.err.Error(), Code: tt.code}, response.Body)
+	}
+}
+
+func TestErrorResponse_Internal(t *testing.T) {
+	err := errors.New("dynamo put item, table go-service-notes-staging, throttled")
+	response := errorResponse(err)
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+	assert.Equal(t, ErrorBody{ErrorMsg: "internal error", Code: CodeInternal, Err: err}, response.Body)
+
+	body, jsonErr := json.Marshal(response.Body)
+	assert.NoError(t, jsonErr)
+	assert.NotContains(t, string(body), "go-service-notes-staging")
+}
+
+func TestErrorResponse_Message(t *testing.T) {
+	err := fmt.Errorf("insert, topic %q: %w", "Ideas", notes.ErrTopicNotFound)
+	assert.Equal(t, `insert, topic "Ideas": topic not found`, errorResponse(err).Body.(ErrorBody).ErrorMsg)
+	assert.True(t, errors.Is(err, notes.ErrNotFound))
+}
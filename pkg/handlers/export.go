@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/export"
+)
+
+// Stream is a Response body produced by Write directly into the response,
+// offered to the client as a download named Filename.
+type Stream struct {
+	ContentType string
+	Filename    string
+	Write       func(w io.Writer) error
+}
+
+type ExportRequest struct {
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+}
+
+// ExportMarkdown returns all of the user's topics, archived ones included, as
+// a zip of Markdown files.
+func (a *API) ExportMarkdown(req *http.Request) Response {
+	var exportRequest = ExportRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &exportRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(exportRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, exportRequest.UserID)
+
+	topics, err := a.Store.GetAllTopicsForUser(req.Context(), exportRequest.UserID)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{http.StatusOK, Stream{
+		ContentType: "application/zip",
+		Filename:    "notes.zip",
+		Write: func(w io.Writer) error {
+			return export.Markdown(w, topics)
+		},
+	}}
+}
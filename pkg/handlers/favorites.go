@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type TopicFlagRequest struct {
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+	Title  string `json:"title,omitempty" validate:"required,max=200"`
+}
+
+type NoteFlagRequest struct {
+	UserID    string `json:"userId,omitempty" validate:"required,uuid"`
+	Title     string `json:"title,omitempty" validate:"required,max=200"`
+	NoteTitle string `json:"noteTitle,omitempty" validate:"required,max=200"`
+}
+
+type GetFavoritesRequest struct {
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+}
+
+func (a *API) PinTopic(req *http.Request) Response {
+	return setTopicFlag(req, "pin", a.Store.SetTopicPinned, true)
+}
+
+func (a *API) UnpinTopic(req *http.Request) Response {
+	return setTopicFlag(req, "unpin", a.Store.SetTopicPinned, false)
+}
+
+func (a *API) FavoriteTopic(req *http.Request) Response {
+	return setTopicFlag(req, "favorite", a.Store.SetTopicFavorite, true)
+}
+
+func (a *API) UnfavoriteTopic(req *http.Request) Response {
+	return setTopicFlag(req, "unfavorite", a.Store.SetTopicFavorite, false)
+}
+
+func (a *API) PinNote(req *http.Request) Response {
+	return setNoteFlag(req, "pin", a.Store.SetNotePinned, true)
+}
+
+func (a *API) UnpinNote(req *http.Request) Response {
+	return setNoteFlag(req, "unpin", a.Store.SetNotePinned, false)
+}
+
+func (a *API) FavoriteNote(req *http.Request) Response {
+	return setNoteFlag(req, "favorite", a.Store.SetNoteFavorite, true)
+}
+
+func (a *API) UnfavoriteNote(req *http.Request) Response {
+	return setNoteFlag(req, "unfavorite", a.Store.SetNoteFavorite, false)
+}
+
+// GetFavorites lists the user's favorite topics and notes across all topics.
+func (a *API) GetFavorites(req *http.Request) Response {
+	var getFavoritesRequest = GetFavoritesRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &getFavoritesRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(getFavoritesRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, getFavoritesRequest.UserID)
+
+	favorites, err := a.Store.GetFavorites(req.Context(), getFavoritesRequest.UserID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return Response{http.StatusOK, favorites}
+}
+
+type topicFlagSetter func(ctx context.Context, userID, title string, value bool) error
+
+type noteFlagSetter func(ctx context.Context, userID, title, noteTitle string, value bool) error
+
+func setTopicFlag(req *http.Request, action string, set topicFlagSetter, value bool) Response {
+	var topicFlagRequest = TopicFlagRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &topicFlagRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(topicFlagRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, topicFlagRequest.UserID)
+
+	err = set(req.Context(), topicFlagRequest.UserID, topicFlagRequest.Title, value)
+	if err != nil {
+		return errorResponse(fmt.Errorf("%s, %w", action, err))
+	}
+
+	return Response{
+		http.StatusOK,
+		nil,
+	}
+}
+
+func setNoteFlag(req *http.Request, action string, set noteFlagSetter, value bool) Response {
+	var noteFlagRequest = NoteFlagRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &noteFlagRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(noteFlagRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, noteFlagRequest.UserID)
+
+	err = set(req.Context(), noteFlagRequest.UserID, noteFlagRequest.Title, noteFlagRequest.NoteTitle, value)
+	if err != nil {
+		return errorResponse(fmt.Errorf("%s, %w", action, err))
+	}
+
+	return Response{
+		http.StatusOK,
+		nil,
+	}
+}
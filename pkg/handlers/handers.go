@@ -5,18 +5,23 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/KyleJonesNV/go-service-notes/pkg/logging"
 	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
 )
 
-// @title           Notes API
-
 const (
 	ErrIDNotFound = "id not found"
 	ErrInvalidPayload = "invalid payload"
 )
 
+// API serves the HTTP endpoints against a notes table.
+type API struct {
+	Store *notes.Store
+}
+
 type Response struct {
 	StatusCode int
 	Body       any
@@ -24,15 +29,25 @@ type Response struct {
 
 type ErrorBody struct {
 	ErrorMsg string `json:"error,omitempty"`
+	// Code identifies the error for programs, see the Code constants.
+	Code string `json:"code,omitempty"`
+	// Errors lists the problems with individual fields, if any.
+	Errors []FieldError `json:"errors,omitempty"`
+	// Err is the cause of an internal error. It is logged, never sent.
+	Err error `json:"-"`
 }
 
 type User struct {
-	ID string `json:"id,omitempty"`
+	ID string `json:"id,omitempty" validate:"required,uuid"`
 }
 
 type Note struct {
-	Title string `json:"title,omitempty"`
-	Content string `json:"content,omitempty"`
+	Title string `json:"title,omitempty" validate:"title"`
+	Content string `json:"content,omitempty" validate:"content"`
+	Position string `json:"position,omitempty"`
+	Tags []string `json:"tags,omitempty" validate:"max=50"`
+	DueAt *time.Time `json:"dueAt,omitempty"`
+	RemindAt *time.Time `json:"remindAt,omitempty"`
 	CreatedAt time.Time `json:"createdAt,omitempty"`
 	UpdatedAt time.Time `json:"updatedAt,omitempty"`
 }
@@ -40,102 +55,111 @@ type Note struct {
 type Topic struct {
 	Title string `json:"title,omitempty"`
 	Notes []Note `json:"notes,omitempty"`
+	Position string `json:"position,omitempty"`
 	CreatedAt time.Time `json:"createdAt,omitempty"`
 	UpdatedAt time.Time `json:"updatedAt,omitempty"`
 }
 
 type InsertTopicRequest struct {
-	UserID string `json:"userId,omitempty"`
-	Title string `json:"title,omitempty"`
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+	Title string `json:"title,omitempty" validate:"required,title"`
+	// Upsert keeps an existing topic with the title instead of failing with
+	// 409 Conflict.
+	Upsert bool `json:"upsert,omitempty"`
 }
 
 type DeleteTopicRequest struct {
-	UserID string `json:"userId,omitempty"`
-	Title string `json:"title,omitempty"`
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+	Title string `json:"title,omitempty" validate:"required,max=200"`
 }
 
 type InsertNoteRequest struct {
-	UserID string `json:"userId,omitempty"`
-	Title string `json:"title,omitempty"`
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+	Title string `json:"title,omitempty" validate:"required,max=200"`
 	Note Note `json:"note,omitempty"`
+	// Template, when set, names a template to build the note from. Fields
+	// fills its custom placeholders and a non-empty Note.Title overrides the
+	// rendered title.
+	Template string `json:"template,omitempty" validate:"max=100"`
+	Fields map[string]string `json:"fields,omitempty" validate:"max=50"`
 }
 
 type DeleteNoteRequest struct {
-	UserID string `json:"userId,omitempty"`
-	Title string `json:"title,omitempty"`
-	NoteTitle string `json:"noteTitle,omitempty"`
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+	Title string `json:"title,omitempty" validate:"required,max=200"`
+	NoteTitle string `json:"noteTitle,omitempty" validate:"required,max=200"`
 }
 
 type GetAllNotesRequest struct {
-	UserID string `json:"userId,omitempty"`
-	Title string `json:"title,omitempty"`
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+	Title string `json:"title,omitempty" validate:"required,max=200"`
 }
 
 
-// getAll godoc
-// @Summary      Get all movies
-// @Description  Get all movies
-// @Tags         movies
-// @Accept       json
-// @Produce      json
-// @Success      200  {object}  []movies.Movie
-// @Failure      400  {object}  ErrorBody
-// @Failure      404  {object}  ErrorBody
-// @Failure      500  {object}  ErrorBody
-// @Router       /getAll [get]
-func GetAllForUser(req *http.Request) Response {
+// logUser records the user a request acts for, so that it appears in
+// everything the request logs.
+func logUser(req *http.Request, userID string) {
+	logging.AddAttrs(req.Context(), "user", userID)
+}
+
+// GetAllForUser lists the user's topics with their notes.
+func (a *API) GetAllForUser(req *http.Request) Response {
 	var user = User{}
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		return Response{
-			http.StatusBadRequest,
-			ErrorBody{err.Error()},
-		}
+		return readError(err)
 	}
 
 	err = json.Unmarshal(body, &user)
 	if err != nil {
 		return Response{
 			http.StatusBadRequest,
-			ErrorBody{ErrInvalidPayload},
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
 		}
 	}	
 
-	
+	page, pageViolations := pageRequest(req)
+	if violations := append(Validate(user), pageViolations...); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, user.ID)
 
-	topics, err := notes.GetAllForUser(req.Context(), user.ID)
+	topics, err := a.Store.GetAllForUser(req.Context(), user.ID)
 	if err != nil {
-		return Response{http.StatusInternalServerError, ErrorBody{err.Error()}}
+		return errorResponse(err)
 	}
-	return Response{http.StatusOK, topics}
+	return Response{http.StatusOK, paginate(topics, page)}
 }
 
-func InsertTopic(req *http.Request) Response {
+func (a *API) InsertTopic(req *http.Request) Response {
 	var insertTopicRequest = InsertTopicRequest{}
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		return Response{
-			http.StatusBadRequest,
-			ErrorBody{err.Error()},
-		}
+		return readError(err)
 	}
 
 	err = json.Unmarshal(body, &insertTopicRequest)
 	if err != nil {
 		return Response{
 			http.StatusBadRequest,
-			ErrorBody{ErrInvalidPayload},
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
 		}
 	}	
 
-	err = notes.InsertTopic(req.Context(), insertTopicRequest.UserID, insertTopicRequest.Title)
+	if violations := Validate(insertTopicRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, insertTopicRequest.UserID)
+
+	if insertTopicRequest.Upsert {
+		_, err = a.Store.EnsureTopic(req.Context(), insertTopicRequest.UserID, insertTopicRequest.Title)
+	} else {
+		err = a.Store.InsertTopic(req.Context(), insertTopicRequest.UserID, insertTopicRequest.Title)
+	}
 	if err != nil {
-		return Response{
-			http.StatusInternalServerError,
-			ErrorBody{fmt.Sprintf("insert, %s", err)},
-		}
+		return errorResponse(fmt.Errorf("insert, %w", err))
 	}
 
 	return Response{
@@ -144,31 +168,30 @@ func InsertTopic(req *http.Request) Response {
 	}
 }
 
-func DeleteTopic(req *http.Request) Response {
+func (a *API) DeleteTopic(req *http.Request) Response {
 	var deleteTopicRequest = DeleteTopicRequest{}
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		return Response{
-			http.StatusBadRequest,
-			ErrorBody{err.Error()},
-		}
+		return readError(err)
 	}
 
 	err = json.Unmarshal(body, &deleteTopicRequest)
 	if err != nil {
 		return Response{
 			http.StatusBadRequest,
-			ErrorBody{err.Error()},
+			ErrorBody{ErrorMsg: err.Error(), Code: CodeInvalidPayload},
 		}
 	}
 
-	err = notes.DeleteTopic(req.Context(), deleteTopicRequest.UserID, deleteTopicRequest.Title)
+	if violations := Validate(deleteTopicRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, deleteTopicRequest.UserID)
+
+	err = a.Store.DeleteTopic(req.Context(), deleteTopicRequest.UserID, deleteTopicRequest.Title)
 	if err != nil {
-		return Response{
-			http.StatusInternalServerError,
-			ErrorBody{err.Error()},
-		}
+		return errorResponse(err)
 	}
 
 	return Response{
@@ -177,36 +200,57 @@ func DeleteTopic(req *http.Request) Response {
 	}
 }
 
-func InsertNote(req *http.Request) Response {
+func (a *API) InsertNote(req *http.Request) Response {
 	var insertNoteRequest = InsertNoteRequest{}
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		return Response{
-			http.StatusBadRequest,
-			ErrorBody{err.Error()},
-		}
+		return readError(err)
 	}
 
 	err = json.Unmarshal(body, &insertNoteRequest)
 	if err != nil {
 		return Response{
 			http.StatusBadRequest,
-			ErrorBody{ErrInvalidPayload},
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
 		}
 	}	
 
+	violations := Validate(insertNoteRequest)
+	if insertNoteRequest.Template == "" && strings.TrimSpace(insertNoteRequest.Note.Title) == "" {
+		violations = append(violations, FieldError{Field: "note.title", Message: "is required without a template"})
+	}
+	if len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, insertNoteRequest.UserID)
+
+	if insertNoteRequest.Template != "" {
+		fromTemplate, err := a.Store.NoteFromTemplate(req.Context(), insertNoteRequest.UserID, insertNoteRequest.Title, insertNoteRequest.Template, insertNoteRequest.Fields)
+		if err != nil {
+			return errorResponse(fmt.Errorf("insert, %w", err))
+		}
+
+		if insertNoteRequest.Note.Title == "" {
+			insertNoteRequest.Note.Title = fromTemplate.Title
+		}
+		insertNoteRequest.Note.Content = fromTemplate.Content
+		if violations := validateRendered(insertNoteRequest.Note); len(violations) > 0 {
+			return invalidRequest(violations)
+		}
+	}
+
 	dbNote := notes.Note{
 		Title: insertNoteRequest.Note.Title,
 		Content: insertNoteRequest.Note.Content,
+		Tags: insertNoteRequest.Note.Tags,
+		DueAt: insertNoteRequest.Note.DueAt,
+		RemindAt: insertNoteRequest.Note.RemindAt,
 	}
 
-	err = notes.InsertNote(req.Context(), insertNoteRequest.UserID, insertNoteRequest.Title, dbNote)
+	err = a.Store.InsertNote(req.Context(), insertNoteRequest.UserID, insertNoteRequest.Title, dbNote)
 	if err != nil {
-		return Response{
-			http.StatusInternalServerError,
-			ErrorBody{fmt.Sprintf("insert, %s", err)},
-		}
+		return errorResponse(fmt.Errorf("insert, %w", err))
 	}
 
 	return Response{
@@ -215,32 +259,30 @@ func InsertNote(req *http.Request) Response {
 	}
 }
 
-func DeleteNote(req *http.Request) Response {
+func (a *API) DeleteNote(req *http.Request) Response {
 	var deleteNoteRequest = DeleteNoteRequest{}
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		return Response{
-			http.StatusBadRequest,
-			ErrorBody{err.Error()},
-		}
+		return readError(err)
 	}
 
 	err = json.Unmarshal(body, &deleteNoteRequest)
 	if err != nil {
 		return Response{
 			http.StatusBadRequest,
-			ErrorBody{ErrInvalidPayload},
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
 		}
 	}	
 
+	if violations := Validate(deleteNoteRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, deleteNoteRequest.UserID)
 
-	err = notes.DeleteNote(req.Context(), deleteNoteRequest.UserID, deleteNoteRequest.Title, deleteNoteRequest.NoteTitle)
+	err = a.Store.DeleteNote(req.Context(), deleteNoteRequest.UserID, deleteNoteRequest.Title, deleteNoteRequest.NoteTitle)
 	if err != nil {
-		return Response{
-			http.StatusInternalServerError,
-			ErrorBody{fmt.Sprintf("delete, %s", err)},
-		}
+		return errorResponse(fmt.Errorf("delete, %w", err))
 	}
 
 	return Response{
@@ -249,28 +291,30 @@ func DeleteNote(req *http.Request) Response {
 	}
 }
 
-func GetAllNotes(req *http.Request) Response {
+func (a *API) GetAllNotes(req *http.Request) Response {
 	var getAllNotesRequest = GetAllNotesRequest{}
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		return Response{
-			http.StatusBadRequest,
-			ErrorBody{err.Error()},
-		}
+		return readError(err)
 	}
 
 	err = json.Unmarshal(body, &getAllNotesRequest)
 	if err != nil {
 		return Response{
 			http.StatusBadRequest,
-			ErrorBody{ErrInvalidPayload},
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
 		}
 	}	
 
-	topics, err := notes.GetUserTopicByTitle(req.Context(), getAllNotesRequest.UserID, getAllNotesRequest.Title)
+	if violations := Validate(getAllNotesRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, getAllNotesRequest.UserID)
+
+	topics, err := a.Store.GetUserTopicByTitle(req.Context(), getAllNotesRequest.UserID, getAllNotesRequest.Title)
 	if err != nil {
-		return Response{http.StatusInternalServerError, ErrorBody{err.Error()}}
+		return errorResponse(err)
 	}
 	return Response{http.StatusOK, topics}
 }
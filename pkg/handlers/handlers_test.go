@@ -2,33 +2,59 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"net/http"
+	"os"
 	"testing"
 
+	"github.com/KyleJonesNV/go-service-notes/pkg/config"
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
 	"github.com/stretchr/testify/assert"
 )
 
+// tableAPI returns handlers backed by a real notes table. The table comes
+// from the service configuration; without NOTES_TABLE_NAME or
+// NOTES_DYNAMODB_ENDPOINT set the test is skipped.
+func tableAPI(t *testing.T) *API {
+	_, table := os.LookupEnv(config.EnvTableName)
+	_, endpoint := os.LookupEnv(config.EnvEndpoint)
+	if testing.Short() || !table && !endpoint {
+		t.Skipf("needs a DynamoDB table, set %s or %s", config.EnvTableName, config.EnvEndpoint)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := notes.NewClient(context.Background(), cfg.Store())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &API{Store: notes.NewStore(client, cfg.TableName)}
+}
+
 func TestInsertTopic_InvalidPayload(t *testing.T) {
 	body := "{'name': 'foo'}"
 	request, err := http.NewRequest(http.MethodGet, "", bytes.NewReader([]byte(body)))
 	if err != nil {
 		t.Fatal(err)
 	}
-	response := InsertTopic(request)
+	response := (&API{}).InsertTopic(request)
 	expected := Response{
 		StatusCode: 400,
-		Body: ErrorBody{ErrorMsg: ErrInvalidPayload},
+		Body: ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
 	}
 	assert.Equal(t, expected, response)
 }
 
 func TestInsertDeleteTopic_ValidPayload(t *testing.T) {
+	api := tableAPI(t)
 	body := `{"userId": "1d7ee7f0-36f5-4e33-a766-26981e62d9cf", "title": "testTopic_InsertValid"}`
 	request, err := http.NewRequest(http.MethodGet, "", bytes.NewReader([]byte(body)))
 	if err != nil {
 		t.Fatal(err)
 	}
-	response := InsertTopic(request)
+	response := api.InsertTopic(request)
 	expected := Response{
 		StatusCode: 200,
 		Body: nil,
@@ -40,7 +66,7 @@ func TestInsertDeleteTopic_ValidPayload(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	response = DeleteTopic(request)
+	response = api.DeleteTopic(request)
 	expected = Response{
 		StatusCode: 200,
 		Body: nil,
@@ -49,12 +75,13 @@ func TestInsertDeleteTopic_ValidPayload(t *testing.T) {
 }
 
 func TestInsertNote(t *testing.T) {
-	body := `{"userId": "1d7ee7f0-36f5-4e33-a766-26981e62d9cf", "title": "testInsert"}`
+	api := tableAPI(t)
+	body := `{"userId": "1d7ee7f0-36f5-4e33-a766-26981e62d9cf", "title": "testInsert", "upsert": true}`
 	request, err := http.NewRequest(http.MethodGet, "", bytes.NewReader([]byte(body)))
 	if err != nil {
 		t.Fatal(err)
 	}
-	response := InsertTopic(request)
+	response := api.InsertTopic(request)
 	expected := Response{
 		StatusCode: 200,
 		Body: nil,
@@ -66,10 +93,19 @@ func TestInsertNote(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	response = InsertNote(request)
+	response = api.InsertNote(request)
 	expected = Response{
 		StatusCode: 200,
 		Body: nil,
 	}
 	assert.Equal(t, expected, response)
+
+	// Note titles are unique in a topic, remove it for the next run.
+	body = `{"userId": "1d7ee7f0-36f5-4e33-a766-26981e62d9cf", "title": "testInsert", "noteTitle": "note_title"}`
+	request, err = http.NewRequest(http.MethodDelete, "", bytes.NewReader([]byte(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response = api.DeleteNote(request)
+	assert.Equal(t, expected, response)
 }
\ No newline at end of file
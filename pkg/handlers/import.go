@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/importer"
+)
+
+type ImportRequest struct {
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+	// Archive is the exported file, base64 encoded: a zip for Markdown and
+	// Notion imports, the .enex document for Evernote.
+	Archive []byte `json:"archive,omitempty" validate:"required"`
+	// Topic receives the notes that have no folder of their own: the root of
+	// a Markdown vault, or every note of an Evernote notebook.
+	Topic  string `json:"topic,omitempty" validate:"title"`
+	DryRun bool   `json:"dryRun,omitempty"`
+}
+
+// ImportMarkdown imports a zipped Markdown vault. With dryRun set nothing is
+// written and the result lists what would be created.
+func (a *API) ImportMarkdown(req *http.Request) Response {
+	return a.runImport(req, func(importRequest ImportRequest) (func(importer.Sink), error) {
+		archive, err := importer.OpenZip(importRequest.Archive)
+		if err != nil {
+			return nil, err
+		}
+		return func(sink importer.Sink) {
+			importer.ParseVault(archive, importer.VaultOptions{DefaultTopic: importRequest.Topic}, sink)
+		}, nil
+	})
+}
+
+// ImportEvernote imports an Evernote .enex notebook export into one topic.
+func (a *API) ImportEvernote(req *http.Request) Response {
+	return a.runImport(req, func(importRequest ImportRequest) (func(importer.Sink), error) {
+		return func(sink importer.Sink) {
+			importer.ParseENEX(bytes.NewReader(importRequest.Archive), "archive", importRequest.Topic, sink)
+		}, nil
+	})
+}
+
+// ImportNotion imports a zipped Notion "Markdown & CSV" export.
+func (a *API) ImportNotion(req *http.Request) Response {
+	return a.runImport(req, func(importRequest ImportRequest) (func(importer.Sink), error) {
+		archive, err := importer.OpenZip(importRequest.Archive)
+		if err != nil {
+			return nil, err
+		}
+		return func(sink importer.Sink) {
+			importer.ParseNotion(archive, sink)
+		}, nil
+	})
+}
+
+// runImport decodes an ImportRequest and imports what the parser returned by
+// open finds. An error from open means the archive is unreadable.
+func (a *API) runImport(req *http.Request, open func(importRequest ImportRequest) (func(importer.Sink), error)) Response {
+	var importRequest = ImportRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &importRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(importRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, importRequest.UserID)
+
+	parse, err := open(importRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: fmt.Sprintf("import, %s", err), Code: CodeInvalidPayload},
+		}
+	}
+
+	result, err := importer.New(a.Store).Import(req.Context(), importRequest.UserID, importRequest.DryRun, parse)
+	if err != nil {
+		return errorResponse(fmt.Errorf("import, %w", err))
+	}
+
+	return Response{http.StatusOK, result}
+}
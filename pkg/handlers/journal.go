@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+type SetJournalRequest struct {
+	UserID   string `json:"userId,omitempty" validate:"required,uuid"`
+	Topic    string `json:"topic,omitempty" validate:"required,title"`
+	TimeZone string `json:"timeZone,omitempty" validate:"required,max=64"`
+}
+
+// JournalRequest selects a journal day. An empty date means today in the
+// journal's time zone.
+type JournalRequest struct {
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+	Date   string `json:"date,omitempty" validate:"max=10"`
+}
+
+type JournalMonthRequest struct {
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+	Month  string `json:"month,omitempty" validate:"required,max=7"`
+}
+
+// SetJournal designates the topic and time zone of the user's journal.
+func (a *API) SetJournal(req *http.Request) Response {
+	var setJournalRequest = SetJournalRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &setJournalRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(setJournalRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, setJournalRequest.UserID)
+
+	journal := notes.Journal{
+		Topic:    setJournalRequest.Topic,
+		TimeZone: setJournalRequest.TimeZone,
+	}
+
+	err = a.Store.SetJournal(req.Context(), setJournalRequest.UserID, journal)
+	if err != nil {
+		return errorResponse(err)
+	}
+
+	return Response{
+		http.StatusOK,
+		nil,
+	}
+}
+
+// GetJournalEntry returns the entry for the requested day, creating it the
+// first time it is asked for.
+func (a *API) GetJournalEntry(req *http.Request) Response {
+	var journalRequest = JournalRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &journalRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(journalRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, journalRequest.UserID)
+
+	note, err := a.Store.GetJournalEntry(req.Context(), journalRequest.UserID, journalRequest.Date)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return Response{http.StatusOK, note}
+}
+
+// GetJournalMonth lists the existing entries of a month.
+func (a *API) GetJournalMonth(req *http.Request) Response {
+	var journalMonthRequest = JournalMonthRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &journalMonthRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	page, pageViolations := pageRequest(req)
+	if violations := append(Validate(journalMonthRequest), pageViolations...); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, journalMonthRequest.UserID)
+
+	entries, err := a.Store.GetJournalMonth(req.Context(), journalMonthRequest.UserID, journalMonthRequest.Month)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return Response{http.StatusOK, paginate(entries, page)}
+}
+
+func (a *API) GetPreviousJournalEntry(req *http.Request) Response {
+	return a.getAdjacentJournalEntry(req, true)
+}
+
+func (a *API) GetNextJournalEntry(req *http.Request) Response {
+	return a.getAdjacentJournalEntry(req, false)
+}
+
+func (a *API) getAdjacentJournalEntry(req *http.Request, previous bool) Response {
+	var journalRequest = JournalRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &journalRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(journalRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, journalRequest.UserID)
+
+	note, err := a.Store.GetAdjacentJournalEntry(req.Context(), journalRequest.UserID, journalRequest.Date, previous)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if note == nil {
+		return Response{http.StatusNotFound, ErrorBody{ErrorMsg: ErrIDNotFound, Code: CodeNotFound}}
+	}
+	return Response{http.StatusOK, note}
+}
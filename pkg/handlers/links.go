@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type GetBacklinksRequest struct {
+	UserID    string `json:"userId,omitempty" validate:"required,uuid"`
+	Title     string `json:"title,omitempty" validate:"required,max=200"`
+	NoteTitle string `json:"noteTitle,omitempty" validate:"required,max=200"`
+}
+
+type RenameNoteRequest struct {
+	UserID    string `json:"userId,omitempty" validate:"required,uuid"`
+	Title     string `json:"title,omitempty" validate:"required,max=200"`
+	NoteTitle string `json:"noteTitle,omitempty" validate:"required,max=200"`
+	NewTitle  string `json:"newTitle,omitempty" validate:"required,title"`
+}
+
+// GetBacklinks lists the notes whose content links to the given note.
+func (a *API) GetBacklinks(req *http.Request) Response {
+	var getBacklinksRequest = GetBacklinksRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &getBacklinksRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	page, pageViolations := pageRequest(req)
+	if violations := append(Validate(getBacklinksRequest), pageViolations...); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, getBacklinksRequest.UserID)
+
+	backlinks, err := a.Store.GetBacklinks(req.Context(), getBacklinksRequest.UserID, getBacklinksRequest.Title, getBacklinksRequest.NoteTitle)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return Response{http.StatusOK, paginate(backlinks, page)}
+}
+
+// GetBrokenLinks lists links in the user's notes that point at missing notes.
+func (a *API) GetBrokenLinks(req *http.Request) Response {
+	var user = User{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &user)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	page, pageViolations := pageRequest(req)
+	if violations := append(Validate(user), pageViolations...); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, user.ID)
+
+	broken, err := a.Store.GetBrokenLinks(req.Context(), user.ID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return Response{http.StatusOK, paginate(broken, page)}
+}
+
+// RenameNote renames a note and rewrites the links that pointed at it.
+func (a *API) RenameNote(req *http.Request) Response {
+	var renameNoteRequest = RenameNoteRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &renameNoteRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(renameNoteRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, renameNoteRequest.UserID)
+
+	err = a.Store.RenameNote(req.Context(), renameNoteRequest.UserID, renameNoteRequest.Title, renameNoteRequest.NoteTitle, renameNoteRequest.NewTitle)
+	if err != nil {
+		return errorResponse(fmt.Errorf("rename, %w", err))
+	}
+
+	return Response{
+		http.StatusOK,
+		nil,
+	}
+}
@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/backup"
+	"github.com/KyleJonesNV/go-service-notes/pkg/importer"
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+// DocsPage is the API documentation UI. It renders the document served at
+// /openapi.json.
+//
+//go:embed docs.html
+var DocsPage []byte
+
+// Operation describes an endpoint for the OpenAPI document.
+type Operation struct {
+	Method  string
+	Path    string
+	ID      string
+	Tag     string
+	Summary string
+	// Request is a value of the JSON request body type, nil if the endpoint
+	// takes no body.
+	Request any
+	// Response is a value of the body type of a successful response, nil if
+	// the body is null or not JSON.
+	Response any
+	// ContentType is set for endpoints that send their successful responses
+	// as-is rather than wrapped like the handler responses.
+	ContentType string
+	// Query names the required query parameters.
+	Query []string
+	// Paginated is set for lists that take the limit and offset query
+	// parameters.
+	Paginated bool
+}
+
+// Operations describes every endpoint the API can serve. Keep it in step with
+// the routes in main.go; a test fails when they differ.
+var Operations = []Operation{
+	{Method: http.MethodGet, Path: "/ping", ID: "ping", Tag: "meta", Summary: "Check the service is up",
+		ContentType: "application/json", Response: struct {
+			Message string `json:"message"`
+		}{}},
+	{Method: http.MethodGet, Path: "/openapi.json", ID: "openAPI", Tag: "meta", Summary: "This document",
+		ContentType: "application/json"},
+	{Method: http.MethodGet, Path: "/docs", ID: "docs", Tag: "meta", Summary: "API documentation",
+		ContentType: "text/html"},
+
+	{Method: http.MethodPost, Path: "/getAllForUser", ID: "getAllForUser", Tag: "topics", Summary: "List the user's topics and their notes",
+		Request: User{}, Response: []notes.Topic{}, Paginated: true},
+	{Method: http.MethodPost, Path: "/insertTopic", ID: "insertTopic", Tag: "topics", Summary: "Create a topic",
+		Request: InsertTopicRequest{}},
+	{Method: http.MethodDelete, Path: "/deleteTopic", ID: "deleteTopic", Tag: "topics", Summary: "Delete a topic and its notes",
+		Request: DeleteTopicRequest{}},
+	{Method: http.MethodPost, Path: "/moveTopic", ID: "moveTopic", Tag: "topics", Summary: "Move a topic to a new position",
+		Request: MoveTopicRequest{}},
+	{Method: http.MethodPost, Path: "/pinTopic", ID: "pinTopic", Tag: "topics", Summary: "Pin a topic",
+		Request: TopicFlagRequest{}},
+	{Method: http.MethodPost, Path: "/unpinTopic", ID: "unpinTopic", Tag: "topics", Summary: "Unpin a topic",
+		Request: TopicFlagRequest{}},
+	{Method: http.MethodPost, Path: "/favoriteTopic", ID: "favoriteTopic", Tag: "topics", Summary: "Mark a topic as a favorite",
+		Request: TopicFlagRequest{}},
+	{Method: http.MethodPost, Path: "/unfavoriteTopic", ID: "unfavoriteTopic", Tag: "topics", Summary: "Unmark a favorite topic",
+		Request: TopicFlagRequest{}},
+	{Method: http.MethodPost, Path: "/getArchivedForUser", ID: "getArchivedForUser", Tag: "topics", Summary: "List the user's archived topics",
+		Request: User{}, Response: []notes.Topic{}, Paginated: true},
+	{Method: http.MethodPost, Path: "/archiveTopic", ID: "archiveTopic", Tag: "topics", Summary: "Archive a topic",
+		Request: TopicFlagRequest{}},
+	{Method: http.MethodPost, Path: "/unarchiveTopic", ID: "unarchiveTopic", Tag: "topics", Summary: "Restore an archived topic",
+		Request: TopicFlagRequest{}},
+
+	{Method: http.MethodPost, Path: "/insertNote", ID: "insertNote", Tag: "notes", Summary: "Add a note to a topic, optionally from a template",
+		Request: InsertNoteRequest{}},
+	{Method: http.MethodPost, Path: "/getAllNotes", ID: "getAllNotes", Tag: "notes", Summary: "Get a topic with its notes",
+		Request: GetAllNotesRequest{}, Response: notes.Topic{}},
+	{Method: http.MethodPost, Path: "/deleteNote", ID: "deleteNote", Tag: "notes", Summary: "Delete a note",
+		Request: DeleteNoteRequest{}},
+	{Method: http.MethodPost, Path: "/moveNote", ID: "moveNote", Tag: "notes", Summary: "Move a note within its topic",
+		Request: MoveNoteRequest{}},
+	{Method: http.MethodPost, Path: "/pinNote", ID: "pinNote", Tag: "notes", Summary: "Pin a note",
+		Request: NoteFlagRequest{}},
+	{Method: http.MethodPost, Path: "/unpinNote", ID: "unpinNote", Tag: "notes", Summary: "Unpin a note",
+		Request: NoteFlagRequest{}},
+	{Method: http.MethodPost, Path: "/favoriteNote", ID: "favoriteNote", Tag: "notes", Summary: "Mark a note as a favorite",
+		Request: NoteFlagRequest{}},
+	{Method: http.MethodPost, Path: "/unfavoriteNote", ID: "unfavoriteNote", Tag: "notes", Summary: "Unmark a favorite note",
+		Request: NoteFlagRequest{}},
+	{Method: http.MethodPost, Path: "/getFavorites", ID: "getFavorites", Tag: "notes", Summary: "List the user's favorite topics and notes",
+		Request: GetFavoritesRequest{}, Response: notes.Favorites{}},
+	{Method: http.MethodPost, Path: "/renameNote", ID: "renameNote", Tag: "notes", Summary: "Rename a note and update the links to it",
+		Request: RenameNoteRequest{}},
+	{Method: http.MethodPost, Path: "/getBacklinks", ID: "getBacklinks", Tag: "notes", Summary: "List the notes linking to a note",
+		Request: GetBacklinksRequest{}, Response: []notes.NoteRef{}, Paginated: true},
+	{Method: http.MethodPost, Path: "/getBrokenLinks", ID: "getBrokenLinks", Tag: "notes", Summary: "List links to notes that do not exist",
+		Request: User{}, Response: []notes.BrokenLink{}, Paginated: true},
+
+	{Method: http.MethodPost, Path: "/getTemplates", ID: "getTemplates", Tag: "templates", Summary: "List the user's note templates",
+		Request: GetTemplatesRequest{}, Response: []notes.Template{}, Paginated: true},
+	{Method: http.MethodPost, Path: "/insertTemplate", ID: "insertTemplate", Tag: "templates", Summary: "Create a note template",
+		Request: InsertTemplateRequest{}},
+	{Method: http.MethodPost, Path: "/updateTemplate", ID: "updateTemplate", Tag: "templates", Summary: "Change a note template",
+		Request: UpdateTemplateRequest{}},
+	{Method: http.MethodDelete, Path: "/deleteTemplate", ID: "deleteTemplate", Tag: "templates", Summary: "Delete a note template",
+		Request: DeleteTemplateRequest{}},
+
+	{Method: http.MethodPost, Path: "/setJournal", ID: "setJournal", Tag: "journal", Summary: "Choose the journal topic and time zone",
+		Request: SetJournalRequest{}},
+	{Method: http.MethodPost, Path: "/getJournalEntry", ID: "getJournalEntry", Tag: "journal", Summary: "Get or create the journal entry for a day",
+		Request: JournalRequest{}, Response: notes.Note{}},
+	{Method: http.MethodPost, Path: "/getJournalMonth", ID: "getJournalMonth", Tag: "journal", Summary: "List the journal entries of a month",
+		Request: JournalMonthRequest{}, Response: []notes.Note{}, Paginated: true},
+	{Method: http.MethodPost, Path: "/getPreviousJournalEntry", ID: "getPreviousJournalEntry", Tag: "journal", Summary: "Get the entry before a day",
+		Request: JournalRequest{}, Response: notes.Note{}},
+	{Method: http.MethodPost, Path: "/getNextJournalEntry", ID: "getNextJournalEntry", Tag: "journal", Summary: "Get the entry after a day",
+		Request: JournalRequest{}, Response: notes.Note{}},
+
+	{Method: http.MethodPost, Path: "/getTasks", ID: "getTasks", Tag: "tasks", Summary: "List the checklist items in the user's notes",
+		Request: GetTasksRequest{}, Response: []notes.TaskRef{}, Paginated: true},
+	{Method: http.MethodPost, Path: "/setTask", ID: "setTask", Tag: "tasks", Summary: "Check or uncheck a checklist item",
+		Request: SetTaskRequest{}},
+	{Method: http.MethodPost, Path: "/setNoteDue", ID: "setNoteDue", Tag: "tasks", Summary: "Set a note's due date and reminder",
+		Request: SetNoteDueRequest{}},
+	{Method: http.MethodPost, Path: "/getDueNotes", ID: "getDueNotes", Tag: "tasks", Summary: "List overdue and upcoming notes",
+		Request: GetDueNotesRequest{}, Response: notes.DueNotes{}},
+
+	{Method: http.MethodPost, Path: "/createCalendarToken", ID: "createCalendarToken", Tag: "calendar", Summary: "Issue a calendar feed token",
+		Request: CreateCalendarTokenRequest{}, Response: CalendarTokenResponse{}},
+	{Method: http.MethodGet, Path: "/calendar.ics", ID: "calendarFeed", Tag: "calendar", Summary: "The iCalendar feed of a token's notes with due dates",
+		ContentType: "text/calendar", Query: []string{"token"}},
+
+	{Method: http.MethodPost, Path: "/importMarkdown", ID: "importMarkdown", Tag: "import", Summary: "Import a zipped Markdown vault",
+		Request: ImportRequest{}, Response: importer.Result{}},
+	{Method: http.MethodPost, Path: "/importEvernote", ID: "importEvernote", Tag: "import", Summary: "Import an Evernote .enex notebook",
+		Request: ImportRequest{}, Response: importer.Result{}},
+	{Method: http.MethodPost, Path: "/importNotion", ID: "importNotion", Tag: "import", Summary: "Import a Notion export",
+		Request: ImportRequest{}, Response: importer.Result{}},
+
+	{Method: http.MethodPost, Path: "/exportMarkdown", ID: "exportMarkdown", Tag: "export", Summary: "Download all topics as a zip of Markdown files",
+		Request: ExportRequest{}, ContentType: "application/zip"},
+	{Method: http.MethodPost, Path: "/exportBackup", ID: "exportBackup", Tag: "export", Summary: "Download a backup of the whole account",
+		Request: ExportRequest{}, ContentType: "application/json"},
+	{Method: http.MethodPost, Path: "/restoreBackup", ID: "restoreBackup", Tag: "export", Summary: "Restore a backup into the account",
+		Request: RestoreBackupRequest{}, Response: backup.RestoreResult{}},
+}
+
+// LookupOperation returns the description of an endpoint.
+func LookupOperation(method, path string) (Operation, bool) {
+	for _, op := range Operations {
+		if op.Method == method && op.Path == path {
+			return op, true
+		}
+	}
+	return Operation{}, false
+}
+
+// OpenAPI returns the OpenAPI 3.1 document describing ops. Schemas are
+// derived from the request and response types, including their validate
+// rules.
+func OpenAPI(ops []Operation) map[string]any {
+	s := &schemas{components: map[string]any{}}
+	paths := map[string]any{}
+	tags := map[string]bool{}
+	for _, op := range ops {
+		item, _ := paths[op.Path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = s.operation(op)
+		tags[op.Tag] = true
+	}
+
+	tagList := []any{}
+	for _, name := range sortedKeys(tags) {
+		tagList = append(tagList, map[string]any{"name": name})
+	}
+
+	s.schema(reflect.TypeOf(ErrorBody{}))
+	s.schema(reflect.TypeOf(Problem{}))
+	s.schema(reflect.TypeOf(Meta{}))
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "Notes API",
+			"version": "1.0.0",
+		},
+		"tags":       tagList,
+		"paths":      paths,
+		"components": map[string]any{"schemas": s.components, "parameters": parameters},
+	}
+}
+
+func (s *schemas) operation(op Operation) map[string]any {
+	responses := map[string]any{}
+	success := map[string]any{"description": "OK"}
+	parameters := []any{}
+	switch {
+	case op.ContentType == "" && op.Response == nil:
+		success["content"] = map[string]any{"application/json": map[string]any{"schema": envelope(nil, false)}}
+		responses["204"] = map[string]any{"description": "No content, with ?envelope=false"}
+		parameters = append(parameters, ref("parameters/envelope"))
+	case op.ContentType == "":
+		data := s.of(op.Response)
+		content := map[string]any{"application/json": map[string]any{"schema": envelope(data, op.Paginated)}}
+		if _, _, _, ok := noteBody(op.Response); ok {
+			content["text/markdown"] = map[string]any{"schema": map[string]any{"type": "string"}}
+			content["text/html"] = map[string]any{"schema": map[string]any{"type": "string"}}
+		}
+		success["content"] = content
+		parameters = append(parameters, ref("parameters/envelope"))
+	case op.Response != nil:
+		success["content"] = map[string]any{op.ContentType: map[string]any{"schema": s.of(op.Response)}}
+	default:
+		success["content"] = map[string]any{op.ContentType: map[string]any{}}
+	}
+	responses["200"] = success
+	responses["default"] = errorResponseSchema("Error")
+
+	operation := map[string]any{
+		"operationId": op.ID,
+		"summary":     op.Summary,
+		"tags":        []any{op.Tag},
+		"responses":   responses,
+	}
+	if op.Request != nil {
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": s.of(op.Request)}},
+		}
+		responses["400"] = errorResponseSchema("The body is not valid JSON")
+		responses["413"] = errorResponseSchema("The body is too large")
+		responses["422"] = errorResponseSchema("The request breaks a validation rule")
+	}
+	if op.Paginated {
+		parameters = append(parameters, ref("parameters/limit"), ref("parameters/offset"))
+	}
+	for _, name := range op.Query {
+		parameters = append(parameters, map[string]any{
+			"name":     name,
+			"in":       "query",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		})
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+	return operation
+}
+
+// parameters are the query parameters shared by operations.
+var parameters = map[string]any{
+	"envelope": map[string]any{
+		"name":        "envelope",
+		"in":          "query",
+		"description": "false returns the bare data without the envelope, and 204 No Content when there is none.",
+		"schema":      map[string]any{"type": "boolean", "default": true},
+	},
+	"limit": map[string]any{
+		"name":        "limit",
+		"in":          "query",
+		"description": "Page size. Without it the whole list is returned.",
+		"schema":      map[string]any{"type": "integer", "minimum": 0, "maximum": MaxPageLimit},
+	},
+	"offset": map[string]any{
+		"name":   "offset",
+		"in":     "query",
+		"schema": map[string]any{"type": "integer", "minimum": 0, "default": 0},
+	},
+}
+
+// envelope is the schema of a handlers.Envelope holding data, nil for
+// responses without data.
+func envelope(data map[string]any, paginated bool) map[string]any {
+	meta := ref("schemas/Meta")
+	if paginated {
+		meta = map[string]any{"allOf": []any{ref("schemas/Meta")}, "required": []any{"pagination"}}
+	}
+	properties := map[string]any{"meta": meta}
+	required := []any{"meta"}
+	if data != nil {
+		properties["data"] = data
+		required = append(required, "data")
+	}
+	return map[string]any{"type": "object", "properties": properties, "required": required}
+}
+
+func errorResponseSchema(description string) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content": map[string]any{
+			"application/json": map[string]any{"schema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"error": ref("schemas/ErrorBody"),
+					"meta":  ref("schemas/Meta"),
+				},
+				"required": []any{"error", "meta"},
+			}},
+			ProblemContentType: map[string]any{"schema": ref("schemas/Problem")},
+		},
+	}
+}
+
+// schemas derives JSON Schemas from Go types the way encoding/json encodes
+// them. Named structs become components referenced by $ref.
+type schemas struct {
+	components map[string]any
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+func (s *schemas) of(v any) map[string]any {
+	return s.schema(reflect.TypeOf(v))
+}
+
+func (s *schemas) schema(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		name := componentName(t)
+		if _, ok := s.components[name]; !ok {
+			// Register the name first so recursive types terminate.
+			s.components[name] = map[string]any{}
+			s.components[name] = s.object(t)
+		}
+		return ref("schemas/" + name)
+	default:
+		return map[string]any{}
+	}
+}
+
+func (s *schemas) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []any{}
+	s.fields(t, properties, &required)
+
+	object := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+func (s *schemas) fields(t reflect.Type, properties map[string]any, required *[]any) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			s.fields(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		schema := s.schema(field.Type)
+		if fieldSchema(field, schema) {
+			*required = append(*required, jsonName(field))
+		}
+		properties[jsonName(field)] = schema
+	}
+}
+
+// componentName names a struct type in components, qualified by its package
+// unless it is one of ours.
+func componentName(t reflect.Type) string {
+	if t.PkgPath() == reflect.TypeOf(Response{}).PkgPath() {
+		return t.Name()
+	}
+	return path.Base(t.PkgPath()) + "." + t.Name()
+}
+
+// ref points at a component, e.g. schemas/Note.
+func ref(component string) map[string]any {
+	return map[string]any{"$ref": "#/components/" + component}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
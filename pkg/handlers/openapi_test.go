@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPI_Schemas(t *testing.T) {
+	op, ok := LookupOperation(http.MethodPost, "/importMarkdown")
+	require.True(t, ok)
+
+	document := OpenAPI([]Operation{op})
+	components := document["components"].(map[string]any)["schemas"].(map[string]any)
+
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"userId":  map[string]any{"type": "string", "format": "uuid", "minLength": 1},
+			"archive": map[string]any{"type": "string", "contentEncoding": "base64"},
+			"topic":   map[string]any{"type": "string", "maxLength": MaxTitleLength, "pattern": titlePattern},
+			"dryRun":  map[string]any{"type": "boolean"},
+		},
+		"required": []any{"userId", "archive"},
+	}, components["ImportRequest"])
+	assert.Contains(t, components, "importer.Result")
+	assert.Contains(t, components, "notes.NoteRef")
+	assert.Contains(t, components, "Problem")
+
+	_, err := json.Marshal(document)
+	assert.NoError(t, err)
+}
+
+func TestOpenAPI_Types(t *testing.T) {
+	type node struct {
+		When     time.Time       `json:"when"`
+		Raw      json.RawMessage `json:"raw"`
+		Children []node          `json:"children,omitempty"`
+		Ignored  string          `json:"-"`
+		hidden   string
+	}
+
+	s := &schemas{components: map[string]any{}}
+	assert.Equal(t, ref("schemas/node"), s.schema(reflect.TypeOf(node{})))
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"when":     map[string]any{"type": "string", "format": "date-time"},
+			"raw":      map[string]any{},
+			"children": map[string]any{"type": "array", "items": ref("schemas/node")},
+		},
+	}, s.components["node"])
+}
+
+func TestOperations_AreUnique(t *testing.T) {
+	ids := map[string]bool{}
+	routes := map[string]bool{}
+	for _, op := range Operations {
+		assert.False(t, ids[op.ID], op.ID)
+		assert.False(t, routes[op.Method+" "+op.Path], op.Path)
+		ids[op.ID] = true
+		routes[op.Method+" "+op.Path] = true
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type MoveTopicRequest struct {
+	UserID   string `json:"userId,omitempty" validate:"required,uuid"`
+	Title    string `json:"title,omitempty" validate:"required,max=200"`
+	Previous string `json:"previous,omitempty" validate:"max=200"`
+	Next     string `json:"next,omitempty" validate:"max=200"`
+}
+
+type MoveNoteRequest struct {
+	UserID    string `json:"userId,omitempty" validate:"required,uuid"`
+	Title     string `json:"title,omitempty" validate:"required,max=200"`
+	NoteTitle string `json:"noteTitle,omitempty" validate:"required,max=200"`
+	Previous  string `json:"previous,omitempty" validate:"max=200"`
+	Next      string `json:"next,omitempty" validate:"max=200"`
+}
+
+// MoveTopic places a topic between the topics titled previous and next.
+func (a *API) MoveTopic(req *http.Request) Response {
+	var moveTopicRequest = MoveTopicRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &moveTopicRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	violations := append(Validate(moveTopicRequest), neighbourViolations(moveTopicRequest.Title, moveTopicRequest.Previous, moveTopicRequest.Next)...)
+	if len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, moveTopicRequest.UserID)
+
+	err = a.Store.MoveTopic(req.Context(), moveTopicRequest.UserID, moveTopicRequest.Title, moveTopicRequest.Previous, moveTopicRequest.Next)
+	if err != nil {
+		return errorResponse(fmt.Errorf("move, %w", err))
+	}
+
+	return Response{
+		http.StatusOK,
+		nil,
+	}
+}
+
+// MoveNote places a note between the notes titled previous and next in the
+// same topic.
+func (a *API) MoveNote(req *http.Request) Response {
+	var moveNoteRequest = MoveNoteRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &moveNoteRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	violations := append(Validate(moveNoteRequest), neighbourViolations(moveNoteRequest.NoteTitle, moveNoteRequest.Previous, moveNoteRequest.Next)...)
+	if len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, moveNoteRequest.UserID)
+
+	err = a.Store.MoveNote(req.Context(), moveNoteRequest.UserID, moveNoteRequest.Title, moveNoteRequest.NoteTitle, moveNoteRequest.Previous, moveNoteRequest.Next)
+	if err != nil {
+		return errorResponse(fmt.Errorf("move, %w", err))
+	}
+
+	return Response{
+		http.StatusOK,
+		nil,
+	}
+}
+
+// neighbourViolations reports neighbours that cannot surround the moved item:
+// the item itself, or the same item on both sides.
+func neighbourViolations(moved, previous, next string) []FieldError {
+	violations := []FieldError{}
+	if previous != "" && previous == moved {
+		violations = append(violations, FieldError{Field: "previous", Message: "must not be the moved item"})
+	}
+	if next != "" && next == moved {
+		violations = append(violations, FieldError{Field: "next", Message: "must not be the moved item"})
+	}
+	if previous != "" && previous == next && previous != moved {
+		violations = append(violations, FieldError{Field: "next", Message: "must differ from previous"})
+	}
+	return violations
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoveTopic_InvalidNeighbours(t *testing.T) {
+	tests := []struct {
+		body   string
+		errors []FieldError
+	}{
+		{`{"userId": "` + testUserID + `", "title": "Work", "previous": "Work"}`, []FieldError{{Field: "previous", Message: "must not be the moved item"}}},
+		{`{"userId": "` + testUserID + `", "title": "Work", "next": "Work"}`, []FieldError{{Field: "next", Message: "must not be the moved item"}}},
+		{`{"userId": "` + testUserID + `", "title": "Work", "previous": "Home", "next": "Home"}`, []FieldError{{Field: "next", Message: "must differ from previous"}}},
+	}
+
+	for _, tt := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/moveTopic", bytes.NewReader([]byte(tt.body)))
+		response := (&API{}).MoveTopic(request)
+		assert.Equal(t, http.StatusUnprocessableEntity, response.StatusCode, tt.body)
+		assert.Equal(t, tt.errors, response.Body.(ErrorBody).Errors, tt.body)
+	}
+}
+
+func TestMoveNote_InvalidNeighbours(t *testing.T) {
+	body := `{"userId": "` + testUserID + `", "title": "Work", "noteTitle": "Report", "previous": "Plan", "next": "Report"}`
+	request := httptest.NewRequest(http.MethodPost, "/moveNote", bytes.NewReader([]byte(body)))
+
+	response := (&API{}).MoveNote(request)
+	assert.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+	assert.Equal(t, []FieldError{{Field: "next", Message: "must not be the moved item"}}, response.Body.(ErrorBody).Errors)
+}
+
+func TestMove_OutOfOrderNeighbours(t *testing.T) {
+	_, err := notes.PositionBetween("B", "A")
+	response := errorResponse(fmt.Errorf("move, position between, %w", err))
+	assert.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+	assert.Equal(t, CodeValidation, response.Body.(ErrorBody).Code)
+}
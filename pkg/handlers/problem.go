@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBase prefixes the error code to form the type of a problem,
+// e.g. /problems/topic_not_found. The README lists the codes.
+const ProblemTypeBase = "/problems/"
+
+// CodePayloadTooLarge is sent when a request body is over the size limit.
+const CodePayloadTooLarge = "payload_too_large"
+
+// Problem is an error in the RFC 7807 problem details format. Code and Errors
+// are extension members.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is a problem with one field of the request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Problem converts the error body of a response with the given status.
+// Instance identifies the request, usually its path.
+func (e ErrorBody) Problem(status int, instance string) Problem {
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   e.ErrorMsg,
+		Instance: instance,
+		Code:     e.Code,
+		Errors:   e.Errors,
+	}
+	if e.Code != "" {
+		problem.Type = ProblemTypeBase + e.Code
+	}
+	return problem
+}
+
+// WantsProblem reports whether a request with the Accept header prefers
+// problem details to the default JSON error body. The client must ask for
+// application/problem+json explicitly and rank it no lower than
+// application/json.
+func WantsProblem(accept string) bool {
+	qualities := acceptQualities(accept)
+	problem := qualities[ProblemContentType]
+	return problem > 0 && problem >= qualities["application/json"]
+}
+
+// PayloadTooLarge is the response for a request body over limit bytes.
+func PayloadTooLarge(limit int64) Response {
+	return Response{
+		http.StatusRequestEntityTooLarge,
+		ErrorBody{ErrorMsg: fmt.Sprintf("request body is over %d bytes", limit), Code: CodePayloadTooLarge},
+	}
+}
+
+// readError is the response for a request body that could not be read.
+func readError(err error) Response {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return PayloadTooLarge(tooLarge.Limit)
+	}
+	return Response{
+		http.StatusBadRequest,
+		ErrorBody{ErrorMsg: err.Error(), Code: CodeInvalidPayload},
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWantsProblem(t *testing.T) {
+	assert.True(t, WantsProblem("application/problem+json"))
+	assert.True(t, WantsProblem("application/problem+json, application/json"))
+	assert.True(t, WantsProblem("application/json;q=0.5, application/problem+json"))
+	assert.False(t, WantsProblem(""))
+	assert.False(t, WantsProblem("*/*"))
+	assert.False(t, WantsProblem("application/json"))
+	assert.False(t, WantsProblem("application/problem+json;q=0.2, application/json"))
+	assert.False(t, WantsProblem("application/problem+json;q=0"))
+}
+
+func TestErrorBody_Problem(t *testing.T) {
+	body := ErrorBody{
+		ErrorMsg: `insert, topic "Ideas": topic not found`,
+		Code:     "topic_not_found",
+		Errors:   []FieldError{{Field: "title", Message: "is required"}},
+	}
+
+	assert.Equal(t, Problem{
+		Type:     "/problems/topic_not_found",
+		Title:    "Not Found",
+		Status:   http.StatusNotFound,
+		Detail:   `insert, topic "Ideas": topic not found`,
+		Instance: "/insertNote",
+		Code:     "topic_not_found",
+		Errors:   []FieldError{{Field: "title", Message: "is required"}},
+	}, body.Problem(http.StatusNotFound, "/insertNote"))
+
+	assert.Equal(t, "about:blank", ErrorBody{ErrorMsg: "boom"}.Problem(http.StatusInternalServerError, "").Type)
+}
+
+func TestReadError_TooLarge(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/insertTopic", bytes.NewReader(make([]byte, 64)))
+	request.Body = http.MaxBytesReader(httptest.NewRecorder(), request.Body, 16)
+
+	_, err := io.ReadAll(request.Body)
+	response := readError(err)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.StatusCode)
+	assert.Equal(t, CodePayloadTooLarge, response.Body.(ErrorBody).Code)
+
+	response = (&API{}).InsertTopic(request)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.StatusCode)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultDueWithin is the upcoming window used when GetDueNotesRequest does
+// not set one.
+const defaultDueWithin = 7 * 24 * time.Hour
+
+// SetNoteDueRequest sets a note's due date and reminder time. Leaving either
+// out clears it.
+type SetNoteDueRequest struct {
+	UserID    string     `json:"userId,omitempty" validate:"required,uuid"`
+	Title     string     `json:"title,omitempty" validate:"required,max=200"`
+	NoteTitle string     `json:"noteTitle,omitempty" validate:"required,max=200"`
+	DueAt     *time.Time `json:"dueAt,omitempty"`
+	RemindAt  *time.Time `json:"remindAt,omitempty"`
+}
+
+// GetDueNotesRequest asks for overdue notes and those due within the next
+// WithinHours hours.
+type GetDueNotesRequest struct {
+	UserID      string `json:"userId,omitempty" validate:"required,uuid"`
+	WithinHours int    `json:"withinHours,omitempty" validate:"min=0,max=8760"`
+}
+
+func (a *API) SetNoteDue(req *http.Request) Response {
+	var setNoteDueRequest = SetNoteDueRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &setNoteDueRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(setNoteDueRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, setNoteDueRequest.UserID)
+
+	err = a.Store.SetNoteDue(req.Context(), setNoteDueRequest.UserID, setNoteDueRequest.Title, setNoteDueRequest.NoteTitle, setNoteDueRequest.DueAt, setNoteDueRequest.RemindAt)
+	if err != nil {
+		return errorResponse(fmt.Errorf("set due, %w", err))
+	}
+
+	return Response{
+		http.StatusOK,
+		nil,
+	}
+}
+
+// GetDueNotes lists the user's overdue and upcoming notes.
+func (a *API) GetDueNotes(req *http.Request) Response {
+	var getDueNotesRequest = GetDueNotesRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &getDueNotesRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(getDueNotesRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, getDueNotesRequest.UserID)
+
+	within := defaultDueWithin
+	if getDueNotesRequest.WithinHours > 0 {
+		within = time.Duration(getDueNotesRequest.WithinHours) * time.Hour
+	}
+
+	due, err := a.Store.GetDueNotes(req.Context(), getDueNotesRequest.UserID, time.Now().UTC(), within)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return Response{http.StatusOK, due}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// GetTasksRequest filters tasks by status: "open", "done" or empty for all.
+type GetTasksRequest struct {
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+	Status string `json:"status,omitempty" validate:"oneof=open|done"`
+}
+
+// SetTaskRequest sets the state of the task on Line of a note. Text is
+// optional; when given, the request fails if the task no longer reads so.
+type SetTaskRequest struct {
+	UserID    string `json:"userId,omitempty" validate:"required,uuid"`
+	Title     string `json:"title,omitempty" validate:"required,max=200"`
+	NoteTitle string `json:"noteTitle,omitempty" validate:"required,max=200"`
+	Line      int    `json:"line" validate:"min=0"`
+	Text      string `json:"text,omitempty" validate:"content"`
+	Done      bool   `json:"done"`
+}
+
+// GetTasks lists the markdown task items across the user's notes.
+func (a *API) GetTasks(req *http.Request) Response {
+	var getTasksRequest = GetTasksRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &getTasksRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	page, pageViolations := pageRequest(req)
+	if violations := append(Validate(getTasksRequest), pageViolations...); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, getTasksRequest.UserID)
+
+	tasks, err := a.Store.GetTasks(req.Context(), getTasksRequest.UserID, getTasksRequest.Status)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return Response{http.StatusOK, paginate(tasks, page)}
+}
+
+// SetTask checks or unchecks a task by rewriting its markdown line.
+func (a *API) SetTask(req *http.Request) Response {
+	var setTaskRequest = SetTaskRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &setTaskRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(setTaskRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, setTaskRequest.UserID)
+
+	err = a.Store.SetTaskDone(req.Context(), setTaskRequest.UserID, setTaskRequest.Title, setTaskRequest.NoteTitle, setTaskRequest.Line, setTaskRequest.Text, setTaskRequest.Done)
+	if err != nil {
+		return errorResponse(fmt.Errorf("set task, %w", err))
+	}
+
+	return Response{
+		http.StatusOK,
+		nil,
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+type Template struct {
+	Name    string `json:"name,omitempty" validate:"required,max=100"`
+	Title   string `json:"title,omitempty" validate:"max=200"`
+	Content string `json:"content,omitempty" validate:"content"`
+}
+
+type InsertTemplateRequest struct {
+	UserID   string   `json:"userId,omitempty" validate:"required,uuid"`
+	Template Template `json:"template,omitempty"`
+}
+
+type UpdateTemplateRequest struct {
+	UserID   string   `json:"userId,omitempty" validate:"required,uuid"`
+	Template Template `json:"template,omitempty"`
+}
+
+type DeleteTemplateRequest struct {
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+	Name   string `json:"name,omitempty" validate:"required,max=100"`
+}
+
+type GetTemplatesRequest struct {
+	UserID string `json:"userId,omitempty" validate:"required,uuid"`
+}
+
+// GetTemplates lists the user's note templates.
+func (a *API) GetTemplates(req *http.Request) Response {
+	var getTemplatesRequest = GetTemplatesRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &getTemplatesRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	page, pageViolations := pageRequest(req)
+	if violations := append(Validate(getTemplatesRequest), pageViolations...); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, getTemplatesRequest.UserID)
+
+	templates, err := a.Store.GetTemplates(req.Context(), getTemplatesRequest.UserID)
+	if err != nil {
+		return errorResponse(err)
+	}
+	return Response{http.StatusOK, paginate(templates, page)}
+}
+
+func (a *API) InsertTemplate(req *http.Request) Response {
+	var insertTemplateRequest = InsertTemplateRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &insertTemplateRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(insertTemplateRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, insertTemplateRequest.UserID)
+
+	err = a.Store.InsertTemplate(req.Context(), insertTemplateRequest.UserID, dbTemplate(insertTemplateRequest.Template))
+	if err != nil {
+		return errorResponse(fmt.Errorf("insert, %w", err))
+	}
+
+	return Response{
+		http.StatusOK,
+		nil,
+	}
+}
+
+func (a *API) UpdateTemplate(req *http.Request) Response {
+	var updateTemplateRequest = UpdateTemplateRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &updateTemplateRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(updateTemplateRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, updateTemplateRequest.UserID)
+
+	err = a.Store.UpdateTemplate(req.Context(), updateTemplateRequest.UserID, dbTemplate(updateTemplateRequest.Template))
+	if err != nil {
+		return errorResponse(fmt.Errorf("update, %w", err))
+	}
+
+	return Response{
+		http.StatusOK,
+		nil,
+	}
+}
+
+func (a *API) DeleteTemplate(req *http.Request) Response {
+	var deleteTemplateRequest = DeleteTemplateRequest{}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return readError(err)
+	}
+
+	err = json.Unmarshal(body, &deleteTemplateRequest)
+	if err != nil {
+		return Response{
+			http.StatusBadRequest,
+			ErrorBody{ErrorMsg: ErrInvalidPayload, Code: CodeInvalidPayload},
+		}
+	}
+
+	if violations := Validate(deleteTemplateRequest); len(violations) > 0 {
+		return invalidRequest(violations)
+	}
+	logUser(req, deleteTemplateRequest.UserID)
+
+	err = a.Store.DeleteTemplate(req.Context(), deleteTemplateRequest.UserID, deleteTemplateRequest.Name)
+	if err != nil {
+		return errorResponse(fmt.Errorf("delete, %w", err))
+	}
+
+	return Response{
+		http.StatusOK,
+		nil,
+	}
+}
+
+func dbTemplate(template Template) notes.Template {
+	return notes.Template{
+		Name:    template.Name,
+		Title:   template.Title,
+		Content: template.Content,
+	}
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits on request fields. A topic and all of its notes are stored in one
+// DynamoDB item of at most 400 KB, which bounds how large a note can be.
+const (
+	MaxTitleLength  = 200
+	MaxContentBytes = 64 << 10
+)
+
+// titleReserved are the characters a title cannot contain, as they have a
+// meaning inside [[wiki links]].
+const titleReserved = "[]|#"
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Request fields declare their rules in a validate tag, separated by commas:
+//
+//	required   the field must not be empty; strings must not be blank
+//	uuid       a UUID such as a user ID
+//	title      at most MaxTitleLength characters, none of them control
+//	           characters or titleReserved, and no surrounding whitespace
+//	content    at most MaxContentBytes bytes
+//	min=n      at least n: characters for strings, the value for numbers
+//	max=n      at most n: characters for strings, items for lists, the
+//	           value for numbers
+//	oneof=a|b  one of the listed values
+//
+// Rules other than required accept empty values. Nested structs are checked
+// with their fields named by path, e.g. note.title.
+type rule struct {
+	name string
+	arg  string
+}
+
+func parseRules(tag string) []rule {
+	rules := []rule{}
+	for _, part := range strings.Split(tag, ",") {
+		if part == "" {
+			continue
+		}
+		name, arg, _ := strings.Cut(part, "=")
+		switch name {
+		case "required", "uuid", "title", "content", "oneof":
+		case "min", "max":
+			if _, err := strconv.Atoi(arg); err != nil {
+				panic(fmt.Sprintf("validate rule %q: %s", part, err))
+			}
+		default:
+			panic(fmt.Sprintf("unknown validate rule %q", part))
+		}
+		rules = append(rules, rule{name, arg})
+	}
+	return rules
+}
+
+// check returns what is wrong with v, or "" if it satisfies the rule.
+func (r rule) check(v reflect.Value) string {
+	if r.name == "required" {
+		if isEmpty(v) {
+			return "is required"
+		}
+		return ""
+	}
+	if isEmpty(v) {
+		return ""
+	}
+
+	switch r.name {
+	case "uuid":
+		if !uuidPattern.MatchString(v.String()) {
+			return "must be a UUID"
+		}
+	case "title":
+		s := v.String()
+		switch {
+		case utf8.RuneCountInString(s) > MaxTitleLength:
+			return fmt.Sprintf("must be at most %d characters", MaxTitleLength)
+		case strings.TrimSpace(s) != s:
+			return "must not start or end with whitespace"
+		case strings.IndexFunc(s, unicode.IsControl) >= 0:
+			return "must not contain control characters"
+		case strings.ContainsAny(s, titleReserved):
+			return fmt.Sprintf("must not contain any of %s", strings.Join(strings.Split(titleReserved, ""), " "))
+		}
+	case "content":
+		if len(v.String()) > MaxContentBytes {
+			return fmt.Sprintf("must be at most %d bytes", MaxContentBytes)
+		}
+	case "oneof":
+		options := strings.Split(r.arg, "|")
+		for _, option := range options {
+			if v.String() == option {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(options, ", "))
+	case "min", "max":
+		limit, _ := strconv.Atoi(r.arg)
+		size, unit := measure(v)
+		if r.name == "min" && size < limit {
+			return fmt.Sprintf("must be at least %d%s", limit, unit)
+		}
+		if r.name == "max" && size > limit {
+			return fmt.Sprintf("must be at most %d%s", limit, unit)
+		}
+	}
+	return ""
+}
+
+// measure returns the size that min and max compare and its unit.
+func measure(v reflect.Value) (int, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), " characters"
+	case reflect.Slice, reflect.Map:
+		return v.Len(), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int()), ""
+	}
+	panic(fmt.Sprintf("min and max do not apply to %s", v.Type()))
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice:
+		if raw, ok := v.Interface().(json.RawMessage); ok {
+			return len(raw) == 0 || string(raw) == "null"
+		}
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// Validate checks a request against the rules in the validate tags of its
+// fields and returns every violation, so a client can fix them all at once.
+func Validate(request any) []FieldError {
+	violations := []FieldError{}
+	validateStruct(reflect.ValueOf(request), "", &violations)
+	return violations
+}
+
+// validateRendered checks a note rendered from a template with the rules of a
+// note in a request, as the template may produce a title the request could
+// not have sent.
+func validateRendered(note Note) []FieldError {
+	violations := []FieldError{}
+	if strings.TrimSpace(note.Title) == "" {
+		violations = append(violations, FieldError{Field: "note.title", Message: "is required"})
+	}
+	validateStruct(reflect.ValueOf(note), "note.", &violations)
+
+	for i := range violations {
+		violations[i].Message += " once the template is rendered"
+	}
+	return violations
+}
+
+func validateStruct(v reflect.Value, prefix string, violations *[]FieldError) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := prefix + jsonName(field)
+		value := v.Field(i)
+		for _, rule := range parseRules(field.Tag.Get("validate")) {
+			if message := rule.check(value); message != "" {
+				*violations = append(*violations, FieldError{Field: name, Message: message})
+			}
+		}
+
+		if value.Kind() == reflect.Struct && field.Tag.Get("validate") != "-" && hasRules(value.Type()) {
+			validateStruct(value, name+".", violations)
+		}
+	}
+}
+
+// hasRules reports whether any field of the struct type has a validate tag.
+func hasRules(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("validate") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonName is the name of the field in JSON documents.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// invalidRequest is the response for a request with violations.
+func invalidRequest(violations []FieldError) Response {
+	problems := make([]string, len(violations))
+	for i, violation := range violations {
+		problems[i] = violation.Field + " " + violation.Message
+	}
+
+	return Response{
+		http.StatusUnprocessableEntity,
+		ErrorBody{
+			ErrorMsg: "invalid request: " + strings.Join(problems, "; "),
+			Code:     CodeValidation,
+			Errors:   violations,
+		},
+	}
+}
+
+// titlePattern is the title rule as an ECMA-262 regular expression, for
+// JSON Schema.
+const titlePattern = `^[^\s\[\]|#\u0000-\u001f\u007f]([^\[\]|#\u0000-\u001f\u007f]*[^\s\[\]|#\u0000-\u001f\u007f])?$`
+
+// fieldSchema adds the validate rules of a field to its JSON Schema and
+// reports whether the field is required.
+func fieldSchema(field reflect.StructField, schema map[string]any) bool {
+	rules := parseRules(field.Tag.Get("validate"))
+	required := false
+	for _, rule := range rules {
+		required = required || rule.name == "required"
+	}
+
+	kind := field.Type.Kind()
+	for _, rule := range rules {
+		limit, _ := strconv.Atoi(rule.arg)
+		switch rule.name {
+		case "required":
+			if kind == reflect.String {
+				schema["minLength"] = 1
+			}
+		case "uuid":
+			schema["format"] = "uuid"
+		case "title":
+			schema["maxLength"] = MaxTitleLength
+			schema["pattern"] = titlePattern
+		case "content":
+			// maxLength counts characters, the limit is in bytes.
+			schema["description"] = fmt.Sprintf("At most %d bytes of UTF-8.", MaxContentBytes)
+		case "oneof":
+			options := strings.Split(rule.arg, "|")
+			if !required {
+				// An optional field may be sent empty.
+				options = append(options, "")
+			}
+			schema["enum"] = options
+		case "min", "max":
+			key := map[reflect.Kind]string{
+				reflect.String: "Length",
+				reflect.Slice:  "Items",
+				reflect.Map:    "Properties",
+			}[kind]
+			switch {
+			case key != "" && rule.name == "min":
+				schema["min"+key] = limit
+			case key != "":
+				schema["max"+key] = limit
+			case rule.name == "min":
+				schema["minimum"] = limit
+			default:
+				schema["maximum"] = limit
+			}
+		}
+	}
+	return required
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testUserID = "1d7ee7f0-36f5-4e33-a766-26981e62d9cf"
+
+func TestValidate_ReportsEveryViolation(t *testing.T) {
+	violations := Validate(InsertNoteRequest{
+		UserID: "not-a-uuid",
+		Note: Note{
+			Title:   " [[Draft]]",
+			Content: strings.Repeat("x", MaxContentBytes+1),
+		},
+	})
+
+	assert.Equal(t, []FieldError{
+		{Field: "userId", Message: "must be a UUID"},
+		{Field: "title", Message: "is required"},
+		{Field: "note.title", Message: "must not start or end with whitespace"},
+		{Field: "note.content", Message: "must be at most 65536 bytes"},
+	}, violations)
+}
+
+func TestValidate_Rules(t *testing.T) {
+	tests := []struct {
+		request any
+		field   string
+		message string
+	}{
+		{InsertTopicRequest{UserID: testUserID, Title: "   "}, "title", "is required"},
+		{InsertTopicRequest{UserID: testUserID, Title: strings.Repeat("é", MaxTitleLength+1)}, "title", "must be at most 200 characters"},
+		{InsertTopicRequest{UserID: testUserID, Title: "a\tb"}, "title", "must not contain control characters"},
+		{InsertTopicRequest{UserID: testUserID, Title: "a|b"}, "title", "must not contain any of [ ] | #"},
+		{RestoreBackupRequest{UserID: testUserID, Mode: "overwrite", Backup: json.RawMessage(`{}`)}, "mode", "must be one of merge, replace"},
+		{RestoreBackupRequest{UserID: testUserID, Mode: "merge", Backup: json.RawMessage(`null`)}, "backup", "is required"},
+		{GetDueNotesRequest{UserID: testUserID, WithinHours: -1}, "withinHours", "must be at least 0"},
+		{Note{Title: "ok", Tags: make([]string, 51)}, "tags", "must be at most 50 items"},
+		{InsertTemplateRequest{UserID: testUserID}, "template.name", "is required"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, []FieldError{{Field: tt.field, Message: tt.message}}, Validate(tt.request), tt.field)
+	}
+
+	assert.Empty(t, Validate(InsertTopicRequest{UserID: testUserID, Title: "Ideas für 2024 (draft)"}))
+	assert.Empty(t, Validate(GetTasksRequest{UserID: testUserID}))
+}
+
+func TestValidateRendered(t *testing.T) {
+	assert.Equal(t, []FieldError{
+		{Field: "note.title", Message: "is required once the template is rendered"},
+	}, validateRendered(Note{Title: " ", Content: "body"}))
+	assert.Equal(t, []FieldError{
+		{Field: "note.title", Message: "must not contain any of [ ] | # once the template is rendered"},
+	}, validateRendered(Note{Title: "Standup #12"}))
+	assert.Equal(t, []FieldError{
+		{Field: "note.content", Message: "must be at most 65536 bytes once the template is rendered"},
+	}, validateRendered(Note{Title: "Standup", Content: strings.Repeat("x", MaxContentBytes+1)}))
+
+	assert.Empty(t, validateRendered(Note{Title: "Standup 2024-03-31", Content: "body"}))
+}
+
+func TestTitlePattern(t *testing.T) {
+	pattern := regexp.MustCompile(strings.NewReplacer(`\u0000`, `\x00`, `\u001f`, `\x1f`, `\u007f`, `\x7f`).Replace(titlePattern))
+	for _, title := range []string{"a", "Ideas für 2024", "x y"} {
+		assert.True(t, pattern.MatchString(title), title)
+		assert.Empty(t, Validate(Note{Title: title}), title)
+	}
+	for _, title := range []string{" a", "a ", "a#b", "[x]", "a\nb"} {
+		assert.False(t, pattern.MatchString(title), title)
+		assert.NotEmpty(t, Validate(Note{Title: title}), title)
+	}
+}
+
+func TestFieldSchema(t *testing.T) {
+	schemaOf := func(v any, name string) (map[string]any, bool) {
+		field, _ := reflect.TypeOf(v).FieldByName(name)
+		schema := map[string]any{}
+		return schema, fieldSchema(field, schema)
+	}
+
+	schema, required := schemaOf(InsertTopicRequest{}, "UserID")
+	assert.True(t, required)
+	assert.Equal(t, map[string]any{"minLength": 1, "format": "uuid"}, schema)
+
+	schema, required = schemaOf(InsertTopicRequest{}, "Title")
+	assert.True(t, required)
+	assert.Equal(t, MaxTitleLength, schema["maxLength"])
+	assert.Equal(t, titlePattern, schema["pattern"])
+
+	schema, required = schemaOf(GetDueNotesRequest{}, "WithinHours")
+	assert.False(t, required)
+	assert.Equal(t, map[string]any{"minimum": 0, "maximum": 8760}, schema)
+
+	schema, _ = schemaOf(Note{}, "Tags")
+	assert.Equal(t, map[string]any{"maxItems": 50}, schema)
+
+	schema, _ = schemaOf(RestoreBackupRequest{}, "Mode")
+	assert.Equal(t, []string{"merge", "replace"}, schema["enum"])
+
+	schema, required = schemaOf(GetTasksRequest{}, "Status")
+	assert.False(t, required)
+	assert.Equal(t, []string{"open", "done", ""}, schema["enum"])
+
+	schema, _ = schemaOf(Note{}, "Content")
+	assert.NotContains(t, schema, "maxLength")
+	assert.Equal(t, fmt.Sprintf("At most %d bytes of UTF-8.", MaxContentBytes), schema["description"])
+}
+
+func TestInvalidRequest(t *testing.T) {
+	response := invalidRequest([]FieldError{{Field: "userId", Message: "is required"}, {Field: "title", Message: "is required"}})
+
+	assert.Equal(t, 422, response.StatusCode)
+	assert.Equal(t, ErrorBody{
+		ErrorMsg: "invalid request: userId is required; title is required",
+		Code:     CodeValidation,
+		Errors:   []FieldError{{Field: "userId", Message: "is required"}, {Field: "title", Message: "is required"}},
+	}, response.Body)
+}
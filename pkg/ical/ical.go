@@ -0,0 +1,144 @@
+// Package ical writes iCalendar (RFC 5545) documents.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest content line allowed before folding, excluding
+// the CRLF (RFC 5545 section 3.1).
+const maxLineOctets = 75
+
+const dateTimeLayout = "20060102T150405Z"
+
+// Calendar is a VCALENDAR object holding events and to-dos.
+type Calendar struct {
+	ProdID     string
+	Name       string
+	Components []Component
+}
+
+// Component is a VEVENT, VTODO or VALARM with its properties in order.
+type Component struct {
+	Kind       string
+	Properties []Property
+	Components []Component
+}
+
+// Property is a single content line. Value must already be encoded for its
+// value type; use Text for free text.
+type Property struct {
+	Name   string
+	Params string
+	Value  string
+}
+
+// Text returns a TEXT property with the value escaped.
+func Text(name, value string) Property {
+	return Property{Name: name, Value: EscapeText(value)}
+}
+
+// DateTime returns a DATE-TIME property in UTC.
+func DateTime(name string, t time.Time) Property {
+	return Property{Name: name, Value: t.UTC().Format(dateTimeLayout)}
+}
+
+// EscapeText escapes a TEXT value: backslashes, semicolons, commas and line
+// breaks (RFC 5545 section 3.3.11).
+func EscapeText(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	value = strings.ReplaceAll(value, "\r", "\n")
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`;`, `\;`,
+		`,`, `\,`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+// Fold splits a content line into lines of at most 75 octets, continuing each
+// with a single space, without breaking UTF-8 sequences. The result has no
+// trailing CRLF.
+func Fold(line string) string {
+	if len(line) <= maxLineOctets {
+		return line
+	}
+
+	var b strings.Builder
+	limit := maxLineOctets
+	width := 0
+	for len(line) > 0 {
+		_, size := utf8.DecodeRuneInString(line)
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 0
+			// The leading space counts towards the continuation line.
+			limit = maxLineOctets - 1
+		}
+		b.WriteString(line[:size])
+		width += size
+		line = line[size:]
+	}
+	return b.String()
+}
+
+// WriteTo writes the calendar with CRLF line endings.
+func (c Calendar) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.property(Property{Name: "PRODID", Value: c.ProdID})
+	cw.line("CALSCALE:GREGORIAN")
+	cw.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		cw.property(Text("X-WR-CALNAME", c.Name))
+	}
+	for _, component := range c.Components {
+		cw.component(component)
+	}
+	cw.line("END:VCALENDAR")
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) line(line string) {
+	if cw.err != nil {
+		return
+	}
+	n, err := cw.w.WriteString(Fold(line) + "\r\n")
+	cw.n += int64(n)
+	cw.err = err
+}
+
+func (cw *countingWriter) property(p Property) {
+	name := p.Name
+	if p.Params != "" {
+		name += ";" + p.Params
+	}
+	cw.line(name + ":" + p.Value)
+}
+
+func (cw *countingWriter) component(c Component) {
+	cw.line("BEGIN:" + c.Kind)
+	for _, p := range c.Properties {
+		cw.property(p)
+	}
+	for _, child := range c.Components {
+		cw.component(child)
+	}
+	cw.line("END:" + c.Kind)
+}
@@ -0,0 +1,102 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeText(t *testing.T) {
+	assert.Equal(t, `a\\b\; c\, d\ne\nf`, EscapeText("a\\b; c, d\r\ne\nf"))
+}
+
+func TestFold(t *testing.T) {
+	assert.Equal(t, "short", Fold("short"))
+
+	line := "DESCRIPTION:" + strings.Repeat("é", 80)
+	folded := Fold(line)
+
+	parts := strings.Split(folded, "\r\n")
+	assert.Greater(t, len(parts), 1)
+	for i, part := range parts {
+		assert.LessOrEqual(t, len(part), maxLineOctets)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(part, " "))
+		}
+		assert.True(t, strings.ToValidUTF8(part, "?") == part)
+	}
+
+	unfolded := strings.ReplaceAll(folded, "\r\n ", "")
+	assert.Equal(t, line, unfolded)
+}
+
+func TestFromNotes(t *testing.T) {
+	due := time.Date(2023, 6, 1, 15, 0, 0, 0, time.UTC)
+	remind := due.Add(-time.Hour)
+	updated := time.Date(2023, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	topics := []notes.Topic{{
+		Title: "Work, misc",
+		Notes: []notes.Note{
+			{ID: "note-1", Title: "Report", Content: "Line one\nLine two", DueAt: &due, RemindAt: &remind, UpdatedAt: updated},
+			{ID: "note-2", Title: "Checklist", DueAt: &due, UpdatedAt: updated, Tasks: []notes.Task{{Done: true}, {Done: true}}},
+			{Title: "No date"},
+		},
+	}}
+
+	var buf bytes.Buffer
+	_, err := FromNotes("user-1", topics).WriteTo(&buf)
+	assert.NoError(t, err)
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.NotContains(t, strings.ReplaceAll(out, "\r\n", ""), "\n")
+
+	assert.Contains(t, out, "BEGIN:VEVENT\r\nUID:"+UID("user-1", "note-1")+"\r\n")
+	assert.Contains(t, out, "DTSTAMP:20230501T080000Z\r\n")
+	assert.Contains(t, out, "DTSTART:20230601T150000Z\r\n")
+	assert.Contains(t, out, "CATEGORIES:Work\\, misc\r\n")
+	assert.Contains(t, out, "DESCRIPTION:Line one\\nLine two\r\n")
+	assert.Contains(t, out, "TRIGGER;VALUE=DATE-TIME:20230601T140000Z\r\n")
+
+	assert.Contains(t, out, "BEGIN:VTODO\r\n")
+	assert.Contains(t, out, "DUE:20230601T150000Z\r\nSTATUS:COMPLETED\r\n")
+	assert.NotContains(t, out, "No date")
+
+	assert.Equal(t, UID("user-1", "note-1"), UID("user-1", "note-1"))
+	assert.NotEqual(t, UID("user-1", "note-1"), UID("user-2", "note-1"))
+	assert.NotEqual(t, UID("user-1", "note-1"), UID("user-1", "note-2"))
+}
+
+func TestFromNotes_RenameKeepsUID(t *testing.T) {
+	due := time.Date(2023, 6, 1, 15, 0, 0, 0, time.UTC)
+	uid := func(topic, note string) string {
+		calendar := FromNotes("user-1", []notes.Topic{{Title: topic, Notes: []notes.Note{{ID: "note-1", Title: note, DueAt: &due}}}})
+		return calendar.Components[0].Properties[0].Value
+	}
+
+	assert.Equal(t, uid("Work", "Report"), uid("Work", "Quarterly report"))
+	assert.Equal(t, uid("Work", "Report"), uid("Office", "Report"))
+}
+
+func TestFromNotes_NotesWithoutIDs(t *testing.T) {
+	due := time.Date(2023, 6, 1, 15, 0, 0, 0, time.UTC)
+	calendar := FromNotes("user-1", []notes.Topic{
+		{Title: "Work", Notes: []notes.Note{{Title: "Report", DueAt: &due}, {Title: "Review", DueAt: &due}}},
+		{Title: "Home", Notes: []notes.Note{{Title: "Report", DueAt: &due}}},
+	})
+
+	uids := map[string]bool{}
+	for _, component := range calendar.Components {
+		uids[component.Properties[0].Value] = true
+	}
+	assert.Len(t, uids, 3)
+
+	again := FromNotes("user-1", []notes.Topic{{Title: "Work", Notes: []notes.Note{{Title: "Report", DueAt: &due}}}})
+	assert.Equal(t, calendar.Components[0].Properties[0].Value, again.Components[0].Properties[0].Value)
+}
@@ -0,0 +1,117 @@
+package ical
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+const prodID = "-//KyleJonesNV//go-service-notes//EN"
+
+// eventLength is how long a deadline without tasks blocks out in a calendar.
+const eventLength = 30 * time.Minute
+
+// FromNotes builds a calendar of every note with a due date. Notes with task
+// items become VTODOs, completed once every task is done; other notes become
+// VEVENTs starting at the due time. A reminder time adds a VALARM.
+func FromNotes(userID string, topics []notes.Topic) Calendar {
+	calendar := Calendar{
+		ProdID: prodID,
+		Name:   "Notes",
+	}
+
+	for _, topic := range topics {
+		for _, note := range topic.Notes {
+			if note.DueAt == nil {
+				continue
+			}
+			calendar.Components = append(calendar.Components, noteComponent(userID, topic.Title, note))
+		}
+	}
+
+	return calendar
+}
+
+func noteComponent(userID, topicTitle string, note notes.Note) Component {
+	stamp := note.UpdatedAt
+	if stamp.IsZero() {
+		stamp = note.CreatedAt
+	}
+
+	properties := []Property{
+		{Name: "UID", Value: noteUID(userID, topicTitle, note)},
+		DateTime("DTSTAMP", stamp),
+	}
+	if !note.CreatedAt.IsZero() {
+		properties = append(properties, DateTime("CREATED", note.CreatedAt))
+	}
+	if !note.UpdatedAt.IsZero() {
+		properties = append(properties, DateTime("LAST-MODIFIED", note.UpdatedAt))
+	}
+	properties = append(properties,
+		Text("SUMMARY", note.Title),
+		Text("CATEGORIES", topicTitle),
+	)
+	if note.Content != "" {
+		properties = append(properties, Text("DESCRIPTION", note.Content))
+	}
+
+	component := Component{}
+	if len(note.Tasks) > 0 {
+		component.Kind = "VTODO"
+		properties = append(properties, DateTime("DUE", *note.DueAt))
+
+		done := 0
+		for _, task := range note.Tasks {
+			if task.Done {
+				done++
+			}
+		}
+		if done == len(note.Tasks) {
+			properties = append(properties, Property{Name: "STATUS", Value: "COMPLETED"})
+		} else {
+			properties = append(properties, Property{Name: "STATUS", Value: "NEEDS-ACTION"})
+		}
+	} else {
+		component.Kind = "VEVENT"
+		properties = append(properties,
+			DateTime("DTSTART", *note.DueAt),
+			DateTime("DTEND", note.DueAt.Add(eventLength)),
+			Property{Name: "TRANSP", Value: "TRANSPARENT"},
+		)
+	}
+	component.Properties = properties
+
+	if note.RemindAt != nil {
+		component.Components = append(component.Components, Component{
+			Kind: "VALARM",
+			Properties: []Property{
+				{Name: "ACTION", Value: "DISPLAY"},
+				{Name: "TRIGGER", Params: "VALUE=DATE-TIME", Value: note.RemindAt.UTC().Format(dateTimeLayout)},
+				Text("DESCRIPTION", note.Title),
+			},
+		})
+	}
+
+	return component
+}
+
+// UID derives a globally unique, stable identifier for a note from its ID, so
+// calendar clients update rather than duplicate entries between refreshes,
+// also when the note or its topic is renamed or the note is moved.
+func UID(userID, noteID string) string {
+	sum := sha1.Sum([]byte(userID + "\x00" + noteID))
+	return hex.EncodeToString(sum[:]) + "@go-service-notes"
+}
+
+// noteUID is the UID of a note. Notes written before notes had IDs have none
+// until they are next saved; their UID comes from their topic and title
+// instead, which is stable as long as neither is renamed.
+func noteUID(userID, topicTitle string, note notes.Note) string {
+	if note.ID == "" {
+		return UID(userID, "\x00"+topicTitle+"\x00"+note.Title)
+	}
+	return UID(userID, note.ID)
+}
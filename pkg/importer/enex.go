@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+const enexTimeLayout = "20060102T150405Z"
+
+// enexNote is a <note> element of an Evernote export. Attachments are not
+// decoded; their data is discarded as the element is read.
+type enexNote struct {
+	Title   string   `xml:"title"`
+	Content string   `xml:"content"`
+	Created string   `xml:"created"`
+	Updated string   `xml:"updated"`
+	Tags    []string `xml:"tag"`
+}
+
+// ParseENEX reads an Evernote .enex export note by note, converting each
+// note's ENML content to markdown. All notes go into topic, which is usually
+// the name of the exported notebook. source names the file in the report.
+func ParseENEX(r io.Reader, source, topic string, sink Sink) {
+	topic = cleanTitle(topic)
+	if topic == "" {
+		topic = DefaultTopic
+	}
+
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+
+	index := 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			sink.Fail(source, fmt.Errorf("enex, %w", err))
+			return
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+
+		index++
+		var raw enexNote
+		err = d.DecodeElement(&raw, &start)
+		if err != nil {
+			sink.Fail(fmt.Sprintf("%s#%d", source, index), fmt.Errorf("enex, %w", err))
+			return
+		}
+
+		name := fmt.Sprintf("%s#%d %s", source, index, raw.Title)
+		note, err := enexToNote(raw)
+		if err != nil {
+			sink.Fail(name, err)
+			continue
+		}
+		sink.Add(Item{Source: name, TopicTitle: topic, Note: note})
+	}
+}
+
+func enexToNote(raw enexNote) (notes.Note, error) {
+	title := cleanTitle(raw.Title)
+	if title == "" {
+		return notes.Note{}, errors.New("note has no title")
+	}
+
+	content, err := ENMLToMarkdown(raw.Content)
+	if err != nil {
+		return notes.Note{}, err
+	}
+
+	note := notes.Note{
+		Title:   title,
+		Content: content,
+		Tags:    notes.NormalizeTags(raw.Tags),
+	}
+	if note.CreatedAt, err = enexTime(raw.Created); err != nil {
+		return notes.Note{}, fmt.Errorf("created, %w", err)
+	}
+	if note.UpdatedAt, err = enexTime(raw.Updated); err != nil {
+		return notes.Note{}, fmt.Errorf("updated, %w", err)
+	}
+	if note.UpdatedAt.IsZero() {
+		note.UpdatedAt = note.CreatedAt
+	}
+
+	return note, nil
+}
+
+func enexTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(enexTimeLayout, value)
+}
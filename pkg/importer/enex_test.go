@@ -0,0 +1,58 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testENEX = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export export-date="20230501T120000Z" application="Evernote" version="10">
+  <note>
+    <title>Groceries</title>
+    <created>20230401T093000Z</created>
+    <updated>20230402T100000Z</updated>
+    <tag>home</tag>
+    <tag>Lists</tag>
+    <content><![CDATA[<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd"><en-note><div><en-todo/>Milk</div></en-note>]]></content>
+    <resource><data encoding="base64">iVBORw0KGgo=</data><mime>image/png</mime></resource>
+  </note>
+  <note>
+    <title>Bad date</title>
+    <created>yesterday</created>
+    <content><![CDATA[<en-note>x</en-note>]]></content>
+  </note>
+  <note>
+    <title>No dates</title>
+    <content><![CDATA[<en-note><p>Plain</p></en-note>]]></content>
+  </note>
+</en-export>`
+
+func TestParseENEX(t *testing.T) {
+	var c Collector
+	ParseENEX(strings.NewReader(testENEX), "Home.enex", "Home", &c)
+
+	assert.Len(t, c.Items, 2)
+	assert.Equal(t, "Home", c.Items[0].TopicTitle)
+	assert.Equal(t, "Groceries", c.Items[0].Note.Title)
+	assert.Equal(t, "- [ ] Milk\n", c.Items[0].Note.Content)
+	assert.Equal(t, []string{"home", "Lists"}, c.Items[0].Note.Tags)
+	assert.Equal(t, time.Date(2023, 4, 1, 9, 30, 0, 0, time.UTC), c.Items[0].Note.CreatedAt)
+	assert.Equal(t, time.Date(2023, 4, 2, 10, 0, 0, 0, time.UTC), c.Items[0].Note.UpdatedAt)
+	assert.Equal(t, "Plain\n", c.Items[1].Note.Content)
+
+	assert.Len(t, c.Failed, 1)
+	assert.Equal(t, "Home.enex#2 Bad date", c.Failed[0].Source)
+}
+
+func TestParseENEX_Truncated(t *testing.T) {
+	var c Collector
+	ParseENEX(strings.NewReader(testENEX[:strings.Index(testENEX, "<title>Bad date")]), "Home.enex", "", &c)
+
+	assert.Len(t, c.Items, 1)
+	assert.Equal(t, DefaultTopic, c.Items[0].TopicTitle)
+	assert.Len(t, c.Failed, 1)
+}
@@ -0,0 +1,370 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// enmlNode is a parsed ENML element or, when Name is empty, a text node.
+type enmlNode struct {
+	Name     string
+	Attrs    map[string]string
+	Text     string
+	Children []*enmlNode
+}
+
+// ENMLToMarkdown converts the ENML body of an Evernote note to markdown.
+// Formatting that markdown cannot express, such as colours and underline, is
+// dropped; attachments are replaced by a placeholder naming their type.
+func ENMLToMarkdown(enml string) (string, error) {
+	root, err := parseENML(enml)
+	if err != nil {
+		return "", err
+	}
+
+	var w mdWriter
+	w.blocks(root.Children)
+
+	return w.String(), nil
+}
+
+func parseENML(enml string) (*enmlNode, error) {
+	d := xml.NewDecoder(strings.NewReader(enml))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	root := &enmlNode{}
+	stack := []*enmlNode{root}
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("enml, %w", err)
+		}
+
+		parent := stack[len(stack)-1]
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			node := &enmlNode{Name: strings.ToLower(tok.Name.Local), Attrs: map[string]string{}}
+			for _, attr := range tok.Attr {
+				node.Attrs[strings.ToLower(attr.Name.Local)] = attr.Value
+			}
+			parent.Children = append(parent.Children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.Children = append(parent.Children, &enmlNode{Text: string(tok)})
+		}
+	}
+
+	// The content is wrapped in en-note; unwrap it if present.
+	for _, child := range root.Children {
+		if child.Name == "en-note" {
+			return child, nil
+		}
+	}
+	return root, nil
+}
+
+// mdWriter renders ENML nodes as markdown. Blocks are separated by blank
+// lines; prefix is prepended to every line, for quotes and list nesting.
+type mdWriter struct {
+	out    strings.Builder
+	line   strings.Builder
+	prefix string
+	// hang replaces prefix on the next line, for list markers.
+	hang string
+	// pending is the number of newlines owed before the next text, and gap
+	// the prefix of the blank lines among them.
+	pending int
+	gap     string
+	started bool
+}
+
+func (w *mdWriter) String() string {
+	w.flush()
+	return strings.TrimRight(w.out.String(), "\n") + "\n"
+}
+
+// flush ends the current line.
+func (w *mdWriter) flush() {
+	if w.line.Len() == 0 {
+		return
+	}
+	text := strings.TrimRight(w.line.String(), " ")
+	w.line.Reset()
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+
+	if w.started {
+		n := w.pending
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				w.out.WriteString(w.gap)
+			}
+			w.out.WriteString("\n")
+		}
+	}
+	if w.hang != "" {
+		w.out.WriteString(w.hang)
+		w.hang = ""
+	} else {
+		w.out.WriteString(w.prefix)
+	}
+	w.out.WriteString(text)
+	w.started = true
+	w.pending = 0
+}
+
+// block ends the current paragraph, so the next text starts after a blank
+// line.
+func (w *mdWriter) block() {
+	w.flush()
+	if w.pending < 2 {
+		w.gap = strings.TrimRight(w.prefix, " ")
+	}
+	w.pending = 2
+}
+
+// newline ends the current line without a blank line.
+func (w *mdWriter) newline() {
+	w.flush()
+	if w.pending == 0 {
+		w.pending = 1
+	}
+}
+
+func (w *mdWriter) text(s string) {
+	w.line.WriteString(s)
+}
+
+var spacePattern = regexp.MustCompile(`\s+`)
+
+func (w *mdWriter) blocks(nodes []*enmlNode) {
+	for _, node := range nodes {
+		w.node(node)
+	}
+}
+
+func (w *mdWriter) node(n *enmlNode) {
+	if n.Name == "" {
+		text := spacePattern.ReplaceAllString(strings.ReplaceAll(n.Text, "\u00a0", " "), " ")
+		if w.line.Len() == 0 {
+			text = strings.TrimLeft(text, " ")
+		}
+		w.text(text)
+		return
+	}
+
+	switch n.Name {
+	case "div", "p", "section", "article", "center":
+		w.block()
+		w.blocks(n.Children)
+		w.block()
+	case "br":
+		w.newline()
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		w.block()
+		w.text(strings.Repeat("#", int(n.Name[1]-'0')) + " ")
+		w.inline(n.Children)
+		w.block()
+	case "b", "strong":
+		w.wrap("**", n.Children)
+	case "i", "em":
+		w.wrap("*", n.Children)
+	case "s", "strike", "del":
+		w.wrap("~~", n.Children)
+	case "code":
+		w.wrap("`", n.Children)
+	case "a":
+		href := n.Attrs["href"]
+		text := inlineText(n.Children)
+		if href == "" || text == href {
+			w.text(firstNonEmpty(text, href))
+		} else {
+			w.text(fmt.Sprintf("[%s](%s)", text, href))
+		}
+	case "img":
+		w.text(fmt.Sprintf("![%s](%s)", n.Attrs["alt"], n.Attrs["src"]))
+	case "en-media":
+		w.text(fmt.Sprintf("[attachment: %s]", firstNonEmpty(n.Attrs["type"], "file")))
+	case "en-todo":
+		if n.Attrs["checked"] == "true" {
+			w.text("- [x] ")
+		} else {
+			w.text("- [ ] ")
+		}
+	case "en-crypt":
+		w.text("[encrypted content]")
+	case "hr":
+		w.block()
+		w.text("---")
+		w.block()
+	case "pre":
+		w.block()
+		w.text("```")
+		w.newline()
+		for _, line := range strings.Split(strings.Trim(allText(n), "\n"), "\n") {
+			w.text(line)
+			w.line.WriteString(" ") // keep blank lines inside the fence
+			w.newline()
+		}
+		w.text("```")
+		w.block()
+	case "blockquote":
+		w.block()
+		saved := w.prefix
+		w.prefix += "> "
+		w.blocks(n.Children)
+		w.flush()
+		w.prefix = saved
+		w.block()
+	case "ul", "ol":
+		w.list(n)
+	case "table":
+		w.block()
+		w.table(n)
+		w.block()
+	case "style", "script", "title", "head":
+	default:
+		w.blocks(n.Children)
+	}
+}
+
+func (w *mdWriter) inline(nodes []*enmlNode) {
+	w.text(inlineText(nodes))
+}
+
+func (w *mdWriter) wrap(marker string, nodes []*enmlNode) {
+	text := inlineText(nodes)
+	if strings.TrimSpace(text) == "" {
+		w.text(text)
+		return
+	}
+	w.text(marker + strings.TrimSpace(text) + marker)
+}
+
+func (w *mdWriter) list(n *enmlNode) {
+	nested := w.line.Len() > 0 || strings.HasSuffix(w.prefix, "  ")
+	if nested {
+		w.flush()
+		w.pending = 1
+	} else {
+		w.block()
+	}
+
+	number := 1
+	for _, item := range n.Children {
+		if item.Name != "li" {
+			continue
+		}
+		marker := "- "
+		if n.Name == "ol" {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+
+		w.newline()
+		saved := w.prefix
+		w.hang = saved + marker
+		w.prefix += strings.Repeat(" ", len(marker))
+		for _, child := range item.Children {
+			if child.Name == "div" || child.Name == "p" {
+				// Evernote wraps list item text in a div; keep it on the
+				// marker's line.
+				w.blocks(child.Children)
+				continue
+			}
+			w.node(child)
+		}
+		w.flush()
+		w.hang = ""
+		w.prefix = saved
+	}
+
+	if !nested {
+		w.block()
+	}
+}
+
+// table renders rows as a markdown table, using the first row as the header.
+func (w *mdWriter) table(n *enmlNode) {
+	var rows [][]string
+	var collect func(nodes []*enmlNode)
+	collect = func(nodes []*enmlNode) {
+		for _, node := range nodes {
+			if node.Name != "tr" {
+				collect(node.Children)
+				continue
+			}
+			row := []string{}
+			for _, cell := range node.Children {
+				if cell.Name == "td" || cell.Name == "th" {
+					text := strings.TrimSpace(spacePattern.ReplaceAllString(allText(cell), " "))
+					row = append(row, strings.ReplaceAll(text, "|", `\|`))
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	collect(n.Children)
+
+	for i, row := range rows {
+		w.newline()
+		w.text("| " + strings.Join(row, " | ") + " |")
+		if i == 0 {
+			w.newline()
+			w.text("|" + strings.Repeat(" --- |", len(row)))
+		}
+	}
+}
+
+// inlineText renders nodes as a single line of markdown.
+func inlineText(nodes []*enmlNode) string {
+	var w mdWriter
+	for _, node := range nodes {
+		w.node(node)
+	}
+	w.flush()
+	return strings.Join(strings.Fields(strings.ReplaceAll(w.out.String(), "\n", " ")), " ")
+}
+
+// allText concatenates the text of n and its descendants, keeping line breaks.
+func allText(n *enmlNode) string {
+	if n.Name == "" {
+		return n.Text
+	}
+	if n.Name == "br" {
+		return "\n"
+	}
+	var b strings.Builder
+	for _, child := range n.Children {
+		b.WriteString(allText(child))
+		if child.Name == "div" || child.Name == "p" {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
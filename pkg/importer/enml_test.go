@@ -0,0 +1,52 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestENMLToMarkdown(t *testing.T) {
+	enml := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note>
+<h1>Trip &amp; plans</h1>
+<div>Some <b>bold</b> and <i>italic</i> text&nbsp;with a <a href="https://example.com">link</a>.</div>
+<div><br/></div>
+<div><en-todo checked="true"/>Book flights</div>
+<div><en-todo/>Pack</div>
+<ul><li><div>One</div></li><li><div>Two</div><ol><li>Nested</li></ol></li></ul>
+<blockquote>Quoted</blockquote>
+<pre>line 1
+line 2</pre>
+<table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td>2|3</td></tr></table>
+<en-media type="image/png" hash="abc"/>
+</en-note>`
+
+	markdown, err := ENMLToMarkdown(enml)
+	assert.NoError(t, err)
+	assert.Equal(t, "# Trip & plans\n"+
+		"\n"+
+		"Some **bold** and *italic* text with a [link](https://example.com).\n"+
+		"\n"+
+		"- [x] Book flights\n"+
+		"\n"+
+		"- [ ] Pack\n"+
+		"\n"+
+		"- One\n"+
+		"- Two\n"+
+		"  1. Nested\n"+
+		"\n"+
+		"> Quoted\n"+
+		"\n"+
+		"```\n"+
+		"line 1\n"+
+		"line 2\n"+
+		"```\n"+
+		"\n"+
+		"| A | B |\n"+
+		"| --- | --- |\n"+
+		"| 1 | 2\\|3 |\n"+
+		"\n"+
+		"[attachment: image/png]\n", markdown)
+}
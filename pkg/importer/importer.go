@@ -0,0 +1,186 @@
+// Package importer brings notes written elsewhere into a user's topics.
+// Each source format has a parser that hands notes to a Sink one at a time,
+// so a large export is never held in memory as a whole. Import feeds them
+// straight into the store, reporting per-item problems instead of stopping at
+// the first one.
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+// DefaultTopic receives the notes that are not inside any folder.
+const DefaultTopic = "Imported"
+
+// Item is one note to import, along with the file it came from.
+type Item struct {
+	Source     string
+	TopicTitle string
+	Note       notes.Note
+}
+
+// FileError is a problem with a single source item. The item is left out and
+// the import carries on.
+type FileError struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// Result summarises an import. In a dry run it describes what would have been
+// created.
+type Result struct {
+	DryRun  bool            `json:"dryRun"`
+	Topics  []string        `json:"topics"`
+	Notes   []notes.NoteRef `json:"notes"`
+	Skipped []string        `json:"skipped"`
+	Failed  []FileError     `json:"failed"`
+}
+
+// Sink receives what a parser finds in a source.
+type Sink interface {
+	// Add imports a parsed note.
+	Add(item Item)
+	// Skip records a source entry that is deliberately not imported.
+	Skip(source string)
+	// Fail records a source entry that could not be parsed.
+	Fail(source string, err error)
+}
+
+// Collector is a Sink that keeps everything in memory.
+type Collector struct {
+	Items   []Item
+	Skipped []string
+	Failed  []FileError
+}
+
+func (c *Collector) Add(item Item) {
+	c.Items = append(c.Items, item)
+}
+
+func (c *Collector) Skip(source string) {
+	c.Skipped = append(c.Skipped, source)
+}
+
+func (c *Collector) Fail(source string, err error) {
+	c.Failed = append(c.Failed, FileError{source, err.Error()})
+}
+
+// Store reads and creates the user's topics and notes.
+type Store interface {
+	GetAllTopicsForUser(ctx context.Context, userID string) ([]notes.Topic, error)
+	InsertTopic(ctx context.Context, userID, title string) error
+	InsertNote(ctx context.Context, userID, title string, note notes.Note) error
+}
+
+// Importer creates parsed items in a Store.
+type Importer struct {
+	Store Store
+}
+
+// New returns an importer backed by the notes table.
+func New(store *notes.Store) *Importer {
+	return &Importer{Store: store}
+}
+
+// Import runs parse and creates each note it produces for the user, adding
+// topics as needed. Notes that already exist, or that repeat an earlier item,
+// are reported as failed and left untouched.
+func (i *Importer) Import(ctx context.Context, userID string, dryRun bool, parse func(sink Sink)) (Result, error) {
+	topics, err := i.Store.GetAllTopicsForUser(ctx, userID)
+	if err != nil {
+		return Result{}, fmt.Errorf("get all topics for user, %w", err)
+	}
+
+	r := &run{
+		ctx:      ctx,
+		store:    i.Store,
+		userID:   userID,
+		dryRun:   dryRun,
+		existing: map[string]*notes.Topic{},
+		created:  map[string]bool{},
+		seen:     map[notes.NoteRef]string{},
+		result: Result{
+			DryRun:  dryRun,
+			Topics:  []string{},
+			Notes:   []notes.NoteRef{},
+			Skipped: []string{},
+			Failed:  []FileError{},
+		},
+	}
+	for j := range topics {
+		r.existing[topics[j].Title] = &topics[j]
+	}
+
+	parse(r)
+
+	return r.result, nil
+}
+
+// run is the Sink of one Import.
+type run struct {
+	ctx      context.Context
+	store    Store
+	userID   string
+	dryRun   bool
+	existing map[string]*notes.Topic
+	created  map[string]bool
+	seen     map[notes.NoteRef]string
+	result   Result
+}
+
+func (r *run) Skip(source string) {
+	r.result.Skipped = append(r.result.Skipped, source)
+}
+
+func (r *run) Fail(source string, err error) {
+	r.result.Failed = append(r.result.Failed, FileError{source, err.Error()})
+}
+
+func (r *run) Add(item Item) {
+	ref := notes.NoteRef{TopicTitle: item.TopicTitle, NoteTitle: item.Note.Title}
+
+	if source, ok := r.seen[ref]; ok {
+		r.Fail(item.Source, fmt.Errorf("note %q in topic %q is also imported from %s", ref.NoteTitle, ref.TopicTitle, source))
+		return
+	}
+	topic, exists := r.existing[item.TopicTitle]
+	if exists && topic.Archived {
+		r.Fail(item.Source, fmt.Errorf("topic %q is archived", item.TopicTitle))
+		return
+	}
+	if exists && topic.HasNote(item.Note.Title) {
+		r.Fail(item.Source, fmt.Errorf("note %q already exists in topic %q", ref.NoteTitle, ref.TopicTitle))
+		return
+	}
+	r.seen[ref] = item.Source
+
+	if !exists && !r.created[item.TopicTitle] {
+		if !r.dryRun {
+			err := r.store.InsertTopic(r.ctx, r.userID, item.TopicTitle)
+			if err != nil {
+				r.Fail(item.Source, fmt.Errorf("insert topic, %w", err))
+				return
+			}
+		}
+		r.created[item.TopicTitle] = true
+		r.result.Topics = append(r.result.Topics, item.TopicTitle)
+	}
+
+	if !r.dryRun {
+		err := r.store.InsertNote(r.ctx, r.userID, item.TopicTitle, item.Note)
+		if err != nil {
+			r.Fail(item.Source, fmt.Errorf("insert note, %w", err))
+			return
+		}
+	}
+	r.result.Notes = append(r.result.Notes, ref)
+}
+
+// cleanTitle collapses whitespace in a title taken from a file name or header.
+func cleanTitle(title string) string {
+	return strings.Join(strings.Fields(title), " ")
+}
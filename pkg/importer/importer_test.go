@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	topics     []notes.Topic
+	inserted   []notes.NoteRef
+	newTopics  []string
+	failTitles map[string]bool
+}
+
+func (s *fakeStore) GetAllTopicsForUser(ctx context.Context, userID string) ([]notes.Topic, error) {
+	return s.topics, nil
+}
+
+func (s *fakeStore) InsertTopic(ctx context.Context, userID, title string) error {
+	s.newTopics = append(s.newTopics, title)
+	return nil
+}
+
+func (s *fakeStore) InsertNote(ctx context.Context, userID, title string, note notes.Note) error {
+	if s.failTitles[note.Title] {
+		return errors.New("boom")
+	}
+	s.inserted = append(s.inserted, notes.NoteRef{TopicTitle: title, NoteTitle: note.Title})
+	return nil
+}
+
+func testItems() []Item {
+	return []Item{
+		{Source: "Work/a.md", TopicTitle: "Work", Note: notes.Note{Title: "A"}},
+		{Source: "Work/b.md", TopicTitle: "Work", Note: notes.Note{Title: "Existing"}},
+		{Source: "New/c.md", TopicTitle: "New", Note: notes.Note{Title: "C"}},
+		{Source: "New/c copy.md", TopicTitle: "New", Note: notes.Note{Title: "C"}},
+		{Source: "Old/d.md", TopicTitle: "Old", Note: notes.Note{Title: "D"}},
+		{Source: "New/e.md", TopicTitle: "New", Note: notes.Note{Title: "E"}},
+	}
+}
+
+func testStore() *fakeStore {
+	return &fakeStore{
+		topics: []notes.Topic{
+			{Title: "Work", Notes: []notes.Note{{Title: "Existing"}}},
+			{Title: "Old", Archived: true},
+		},
+		failTitles: map[string]bool{"E": true},
+	}
+}
+
+func TestImport(t *testing.T) {
+	store := testStore()
+	importer := &Importer{Store: store}
+
+	result, err := importer.Import(context.Background(), "user", false, func(sink Sink) {
+		sink.Skip("x.png")
+		sink.Fail("bad.md", errors.New("bad"))
+		for _, item := range testItems() {
+			sink.Add(item)
+		}
+	})
+	assert.NoError(t, err)
+
+	assert.False(t, result.DryRun)
+	assert.Equal(t, []string{"New"}, result.Topics)
+	assert.Equal(t, []string{"New"}, store.newTopics)
+	assert.Equal(t, []notes.NoteRef{{TopicTitle: "Work", NoteTitle: "A"}, {TopicTitle: "New", NoteTitle: "C"}}, result.Notes)
+	assert.Equal(t, result.Notes, store.inserted)
+	assert.Equal(t, []string{"x.png"}, result.Skipped)
+
+	sources := []string{}
+	for _, failure := range result.Failed {
+		sources = append(sources, failure.Source)
+	}
+	assert.Equal(t, []string{"bad.md", "Work/b.md", "New/c copy.md", "Old/d.md", "New/e.md"}, sources)
+}
+
+func TestImport_DryRun(t *testing.T) {
+	store := testStore()
+	importer := &Importer{Store: store}
+
+	result, err := importer.Import(context.Background(), "user", true, func(sink Sink) {
+		for _, item := range testItems() {
+			sink.Add(item)
+		}
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, result.DryRun)
+	assert.Equal(t, []string{"New"}, result.Topics)
+	assert.Len(t, result.Notes, 3)
+	assert.Len(t, result.Failed, 3)
+	assert.Empty(t, store.newTopics)
+	assert.Empty(t, store.inserted)
+}
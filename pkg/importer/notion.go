@@ -0,0 +1,227 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+// notionIDPattern matches the id Notion appends to exported file names.
+var notionIDPattern = regexp.MustCompile(`\s+[0-9a-f]{32}$`)
+
+// notionLinkPattern matches markdown links to other pages of the export.
+var notionLinkPattern = regexp.MustCompile(`\[([^\]\n]*)\]\(([^)\s]+\.md)\)`)
+
+var notionTimeLayouts = []string{
+	"January 2, 2006 3:04 PM",
+	"January 2, 2006",
+	time.RFC3339,
+}
+
+// ParseNotion reads a Notion "Markdown & CSV" export. Every top-level page or
+// database becomes a topic holding the page and all pages nested under it.
+// Database rows come from their exported pages; a CSV row without a page
+// becomes a note listing its properties. Links between exported pages become
+// wiki links.
+func ParseNotion(r *zip.Reader, sink Sink) {
+	pages := map[string]bool{}
+	for _, f := range r.File {
+		if strings.EqualFold(path.Ext(f.Name), ".md") {
+			pages[notionKey(path.Dir(f.Name), notionTitle(f.Name))] = true
+		}
+	}
+
+	for _, f := range r.File {
+		name := strings.TrimPrefix(path.Clean("/"+f.Name), "/")
+		if f.FileInfo().IsDir() || name == "" {
+			continue
+		}
+
+		switch strings.ToLower(path.Ext(name)) {
+		case ".md":
+			note, err := readNotionPage(f)
+			if err != nil {
+				sink.Fail(name, err)
+				continue
+			}
+			if note.Title == "" {
+				note.Title = notionTitle(name)
+			}
+			sink.Add(Item{Source: name, TopicTitle: notionTopic(name), Note: note})
+		case ".csv":
+			readNotionDatabase(f, name, pages, sink)
+		default:
+			sink.Skip(name)
+		}
+	}
+}
+
+// notionTitle is the page title encoded in an exported file or folder name.
+func notionTitle(name string) string {
+	base := path.Base(name)
+	base = strings.TrimSuffix(base, path.Ext(base))
+	return cleanTitle(notionIDPattern.ReplaceAllString(base, ""))
+}
+
+// notionTopic is the title of the top-level page a file belongs to.
+func notionTopic(name string) string {
+	first, _, _ := strings.Cut(name, "/")
+	return notionTitle(first)
+}
+
+func notionKey(dir, title string) string {
+	return dir + "/" + title
+}
+
+func readNotionPage(f *zip.File) (notes.Note, error) {
+	data, err := readLimited(f)
+	if err != nil {
+		return notes.Note{}, err
+	}
+
+	text := strings.ReplaceAll(strings.TrimPrefix(string(data), "\ufeff"), "\r\n", "\n")
+
+	note := notes.Note{}
+	if heading, rest, ok := strings.Cut(text, "\n"); strings.HasPrefix(heading, "# ") {
+		note.Title = cleanTitle(strings.TrimPrefix(heading, "# "))
+		if ok {
+			text = strings.TrimLeft(rest, "\n")
+		} else {
+			text = ""
+		}
+	}
+
+	// Database rows start with a block of "Property: value" lines.
+	for _, line := range strings.Split(text, "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok || line == "" {
+			break
+		}
+		applyNotionProperty(&note, key, value)
+	}
+
+	note.Content = notionLinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		match := notionLinkPattern.FindStringSubmatch(link)
+		if strings.Contains(match[2], "://") {
+			return link
+		}
+		target, err := url.PathUnescape(match[2])
+		if err != nil {
+			return link
+		}
+		return "[[" + notionTitle(target) + "]]"
+	})
+	if note.UpdatedAt.IsZero() && !f.Modified.IsZero() {
+		note.UpdatedAt = f.Modified.UTC()
+	}
+	if note.CreatedAt.IsZero() {
+		note.CreatedAt = note.UpdatedAt
+	}
+
+	return note, nil
+}
+
+// applyNotionProperty picks up the properties that map onto note fields.
+func applyNotionProperty(note *notes.Note, key, value string) {
+	switch strings.ToLower(strings.TrimSpace(key)) {
+	case "tags", "tag":
+		note.Tags = notes.NormalizeTags(strings.Split(value, ","))
+	case "created", "created time":
+		note.CreatedAt = notionTime(value)
+	case "last edited", "last edited time", "updated":
+		note.UpdatedAt = notionTime(value)
+	}
+}
+
+func notionTime(value string) time.Time {
+	for _, layout := range notionTimeLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+// maxDatabaseSize caps the uncompressed size of a database CSV. Its rows are
+// streamed, but a single field could otherwise hold the whole expanded file.
+const maxDatabaseSize = 16 << 20
+
+// readNotionDatabase streams the rows of a database CSV. The first column is
+// the row's title.
+func readNotionDatabase(f *zip.File, name string, pages map[string]bool, sink Sink) {
+	// The zip reader fails once an entry outgrows its declared size, so
+	// checking that bounds what is read.
+	if f.UncompressedSize64 > maxDatabaseSize {
+		sink.Fail(name, fmt.Errorf("larger than %d bytes", maxDatabaseSize))
+		return
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		sink.Fail(name, fmt.Errorf("open, %w", err))
+		return
+	}
+	defer rc.Close()
+
+	rows := csv.NewReader(rc)
+	rows.FieldsPerRecord = -1
+
+	header, err := rows.Read()
+	if err != nil {
+		sink.Fail(name, fmt.Errorf("csv header, %w", err))
+		return
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	// Row pages live in a folder named like the CSV.
+	dir := strings.TrimSuffix(name, path.Ext(name))
+	topic := notionTopic(name)
+
+	for line := 2; ; line++ {
+		record, err := rows.Read()
+		if err == io.EOF {
+			return
+		}
+		source := fmt.Sprintf("%s:%d", name, line)
+		if err != nil {
+			sink.Fail(source, fmt.Errorf("csv, %w", err))
+			return
+		}
+		if len(record) == 0 || cleanTitle(record[0]) == "" {
+			sink.Skip(source)
+			continue
+		}
+
+		title := cleanTitle(record[0])
+		if pages[notionKey(dir, title)] {
+			// Imported from the row's page.
+			continue
+		}
+
+		note := notes.Note{Title: title}
+		var content strings.Builder
+		for i := 1; i < len(record) && i < len(header); i++ {
+			if record[i] == "" {
+				continue
+			}
+			applyNotionProperty(&note, header[i], record[i])
+			fmt.Fprintf(&content, "%s: %s\n", header[i], record[i])
+		}
+		note.Content = content.String()
+		if note.CreatedAt.IsZero() {
+			note.CreatedAt = note.UpdatedAt
+		}
+
+		sink.Add(Item{Source: source, TopicTitle: topic, Note: note})
+	}
+}
@@ -0,0 +1,84 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNotion(t *testing.T) {
+	files := []struct{ name, content string }{
+		{"Projects 0123456789abcdef0123456789abcdef.md", "# Projects\n\nSee [Alpha](Projects%200123456789abcdef0123456789abcdef/Alpha%20fedcba9876543210fedcba9876543210.md) and [site](https://example.com/a.md)."},
+		{"Projects 0123456789abcdef0123456789abcdef/Alpha fedcba9876543210fedcba9876543210.md", "# Alpha\n\nDetails"},
+		{"Tasks 00000000000000000000000000000001.csv", "\ufeffName,Tags,Created\nWrite report,\"work, q2\",\"April 1, 2023 9:30 AM\"\nCall Bob,,\n,,\n"},
+		{"Tasks 00000000000000000000000000000001/Write report 00000000000000000000000000000002.md", "# Write report\n\nTags: work, q2\nCreated: April 1, 2023 9:30 AM\n\nDraft"},
+		{"Projects 0123456789abcdef0123456789abcdef/diagram.png", "png"},
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, file := range files {
+		f, err := w.Create(file.name)
+		assert.NoError(t, err)
+		_, err = f.Write([]byte(file.content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+
+	r, err := OpenZip(buf.Bytes())
+	assert.NoError(t, err)
+
+	var c Collector
+	ParseNotion(r, &c)
+
+	byTitle := map[string]Item{}
+	for _, item := range c.Items {
+		byTitle[item.Note.Title] = item
+	}
+	assert.Len(t, c.Items, 4)
+
+	assert.Equal(t, "Projects", byTitle["Projects"].TopicTitle)
+	assert.Equal(t, "See [[Alpha]] and [site](https://example.com/a.md).", byTitle["Projects"].Note.Content)
+	assert.Equal(t, "Projects", byTitle["Alpha"].TopicTitle)
+	assert.Equal(t, "Details", byTitle["Alpha"].Note.Content)
+
+	report := byTitle["Write report"]
+	assert.Equal(t, "Tasks", report.TopicTitle)
+	assert.Equal(t, []string{"work", "q2"}, report.Note.Tags)
+	assert.Equal(t, time.Date(2023, 4, 1, 9, 30, 0, 0, time.UTC), report.Note.CreatedAt)
+
+	assert.Equal(t, "Tasks", byTitle["Call Bob"].TopicTitle)
+
+	assert.ElementsMatch(t, []string{"Projects 0123456789abcdef0123456789abcdef/diagram.png", "Tasks 00000000000000000000000000000001.csv:4"}, c.Skipped)
+	assert.Empty(t, c.Failed)
+}
+
+func TestParseNotion_LargeDatabase(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("Tasks 00000000000000000000000000000001.csv")
+	assert.NoError(t, err)
+	_, err = f.Write([]byte("Name,Notes\nBig,\"" + strings.Repeat("a", maxDatabaseSize) + "\"\n"))
+	assert.NoError(t, err)
+	f, err = w.Create("Projects 0123456789abcdef0123456789abcdef.md")
+	assert.NoError(t, err)
+	_, err = f.Write([]byte("# Projects"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	r, err := OpenZip(buf.Bytes())
+	assert.NoError(t, err)
+
+	var c Collector
+	ParseNotion(r, &c)
+
+	assert.Len(t, c.Items, 1)
+	assert.Equal(t, "Projects", c.Items[0].Note.Title)
+	if assert.Len(t, c.Failed, 1) {
+		assert.Equal(t, "Tasks 00000000000000000000000000000001.csv", c.Failed[0].Source)
+	}
+}
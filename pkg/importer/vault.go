@@ -0,0 +1,241 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"gopkg.in/yaml.v3"
+)
+
+// maxFileSize caps the uncompressed size of a single imported file, so a
+// small archive cannot expand into an arbitrarily large note.
+const maxFileSize = 1 << 20
+
+var errNoFrontmatterEnd = errors.New("frontmatter is not closed with ---")
+
+// VaultOptions controls how a vault is mapped to topics.
+type VaultOptions struct {
+	// DefaultTopic receives notes at the root of the vault. Empty means
+	// DefaultTopic.
+	DefaultTopic string
+}
+
+// ParseVault reads an Obsidian-style vault from a zip archive. Each folder
+// becomes a topic, nested folders joined with " - ", and each .md file a note
+// titled after the file name unless its frontmatter sets a title. Hidden
+// files, such as the .obsidian settings folder, and non-markdown files are
+// skipped.
+func ParseVault(r *zip.Reader, opts VaultOptions, sink Sink) {
+	defaultTopic := opts.DefaultTopic
+	if defaultTopic == "" {
+		defaultTopic = DefaultTopic
+	}
+
+	for _, f := range r.File {
+		name := strings.TrimPrefix(path.Clean("/"+f.Name), "/")
+		if f.FileInfo().IsDir() || name == "" {
+			continue
+		}
+		if hidden(name) || !strings.EqualFold(path.Ext(name), ".md") {
+			sink.Skip(name)
+			continue
+		}
+
+		item, err := readVaultFile(f, name, defaultTopic)
+		if err != nil {
+			sink.Fail(name, err)
+			continue
+		}
+		sink.Add(item)
+	}
+}
+
+func readVaultFile(f *zip.File, name, defaultTopic string) (Item, error) {
+	data, err := readLimited(f)
+	if err != nil {
+		return Item{}, err
+	}
+
+	note, err := ParseMarkdown(string(data))
+	if err != nil {
+		return Item{}, err
+	}
+	if note.Title == "" {
+		note.Title = cleanTitle(strings.TrimSuffix(path.Base(name), path.Ext(name)))
+	}
+	if note.Title == "" {
+		return Item{}, errors.New("note has no title")
+	}
+	if note.UpdatedAt.IsZero() && !f.Modified.IsZero() {
+		note.UpdatedAt = f.Modified.UTC()
+	}
+	if note.CreatedAt.IsZero() {
+		note.CreatedAt = note.UpdatedAt
+	}
+
+	topic := defaultTopic
+	if dir := path.Dir(name); dir != "." {
+		topic = cleanTitle(strings.Join(strings.Split(dir, "/"), " - "))
+	}
+
+	return Item{Source: name, TopicTitle: topic, Note: note}, nil
+}
+
+// ParseMarkdown splits a markdown file into its optional YAML frontmatter and
+// content. Recognised frontmatter keys are title, created (or date), updated
+// (or modified), tags and due; others are ignored.
+func ParseMarkdown(text string) (notes.Note, error) {
+	text = strings.TrimPrefix(text, "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	header, content, err := splitFrontmatter(text)
+	if err != nil {
+		return notes.Note{}, err
+	}
+
+	note := notes.Note{Content: content}
+	if header == "" {
+		return note, nil
+	}
+
+	var fields map[string]any
+	err = yaml.Unmarshal([]byte(header), &fields)
+	if err != nil {
+		return notes.Note{}, fmt.Errorf("frontmatter, %w", err)
+	}
+
+	if title, ok := fields["title"].(string); ok {
+		note.Title = cleanTitle(title)
+	}
+	if note.CreatedAt, err = frontmatterTime(fields, "created", "date"); err != nil {
+		return notes.Note{}, err
+	}
+	if note.UpdatedAt, err = frontmatterTime(fields, "updated", "modified"); err != nil {
+		return notes.Note{}, err
+	}
+	due, err := frontmatterTime(fields, "due")
+	if err != nil {
+		return notes.Note{}, err
+	}
+	if !due.IsZero() {
+		note.DueAt = &due
+	}
+	note.Tags = notes.NormalizeTags(frontmatterTags(fields["tags"]))
+
+	return note, nil
+}
+
+// splitFrontmatter returns the YAML between a leading --- line and the next
+// --- or ... line, and the content after it.
+func splitFrontmatter(text string) (header, content string, err error) {
+	if !strings.HasPrefix(text, "---\n") {
+		return "", text, nil
+	}
+
+	lines := strings.SplitAfter(text[len("---\n"):], "\n")
+	for i, line := range lines {
+		if trimmed := strings.TrimRight(line, " \t\n"); trimmed == "---" || trimmed == "..." {
+			header = strings.Join(lines[:i], "")
+			content = strings.TrimPrefix(strings.Join(lines[i+1:], ""), "\n")
+			return header, content, nil
+		}
+	}
+
+	return "", "", errNoFrontmatterEnd
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// frontmatterTime reads the first of keys that is set. YAML timestamps decode
+// to time.Time; quoted values are parsed with the common layouts.
+func frontmatterTime(fields map[string]any, keys ...string) (time.Time, error) {
+	for _, key := range keys {
+		switch value := fields[key].(type) {
+		case nil:
+			continue
+		case time.Time:
+			return value.UTC(), nil
+		case string:
+			for _, layout := range timeLayouts {
+				if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+					return t.UTC(), nil
+				}
+			}
+			return time.Time{}, fmt.Errorf("frontmatter %s: cannot parse %q as a time", key, value)
+		default:
+			return time.Time{}, fmt.Errorf("frontmatter %s: expected a time", key)
+		}
+	}
+	return time.Time{}, nil
+}
+
+// frontmatterTags accepts a YAML list or a string of tags separated by commas
+// or spaces, as Obsidian does.
+func frontmatterTags(value any) []string {
+	switch value := value.(type) {
+	case string:
+		return strings.FieldsFunc(value, func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+	case []any:
+		tags := []string{}
+		for _, tag := range value {
+			if s, ok := tag.(string); ok {
+				tags = append(tags, s)
+			} else if tag != nil {
+				tags = append(tags, fmt.Sprint(tag))
+			}
+		}
+		return tags
+	}
+	return nil
+}
+
+func hidden(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenZip reads a zip archive held in memory.
+func OpenZip(data []byte) (*zip.Reader, error) {
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// readLimited reads a file of at most maxFileSize bytes of UTF-8 text.
+func readLimited(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open, %w", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read, %w", err)
+	}
+	if len(data) > maxFileSize {
+		return nil, fmt.Errorf("larger than %d bytes", maxFileSize)
+	}
+	if !utf8.Valid(data) {
+		return nil, errors.New("not valid UTF-8")
+	}
+	return data, nil
+}
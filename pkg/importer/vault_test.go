@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	note, err := ParseMarkdown("---\r\n" +
+		"title: Weekly plan\r\n" +
+		"created: 2023-04-01T09:30:00Z\r\n" +
+		"updated: \"2023-04-03 10:00\"\r\n" +
+		"tags: [work, \"#planning\", Work]\r\n" +
+		"aliases: [plan]\r\n" +
+		"---\r\n" +
+		"\r\n" +
+		"# Plan\r\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "Weekly plan", note.Title)
+	assert.Equal(t, time.Date(2023, 4, 1, 9, 30, 0, 0, time.UTC), note.CreatedAt)
+	assert.Equal(t, time.Date(2023, 4, 3, 10, 0, 0, 0, time.UTC), note.UpdatedAt)
+	assert.Equal(t, []string{"work", "planning"}, note.Tags)
+	assert.Equal(t, "# Plan\n", note.Content)
+}
+
+func TestParseMarkdown_TagString(t *testing.T) {
+	note, err := ParseMarkdown("---\ntags: one, two three\n---\nbody")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "three"}, note.Tags)
+	assert.Equal(t, "body", note.Content)
+}
+
+func TestParseMarkdown_NoFrontmatter(t *testing.T) {
+	note, err := ParseMarkdown("just text\n---\nmore")
+	assert.NoError(t, err)
+	assert.Equal(t, "just text\n---\nmore", note.Content)
+}
+
+func TestParseMarkdown_Errors(t *testing.T) {
+	_, err := ParseMarkdown("---\ntitle: x\n")
+	assert.ErrorIs(t, err, errNoFrontmatterEnd)
+
+	_, err = ParseMarkdown("---\ncreated: yesterday\n---\n")
+	assert.ErrorContains(t, err, "created")
+
+	_, err = ParseMarkdown("---\ntitle: [unclosed\n---\n")
+	assert.ErrorContains(t, err, "frontmatter")
+}
+
+func TestParseVault(t *testing.T) {
+	modified := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"Inbox note.md":            "root",
+		"Work/Plan.md":             "---\ntitle: The plan\n---\nplan",
+		"Work/Projects/Alpha.md":   "alpha",
+		"Work/diagram.png":         "png",
+		".obsidian/workspace.json": "{}",
+		"Work/Broken.md":           "---\ntitle: x\n",
+		"Work/Binary.md":           "\xff\xfe",
+	} {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: name, Modified: modified})
+		assert.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+
+	r, err := OpenZip(buf.Bytes())
+	assert.NoError(t, err)
+
+	var c Collector
+	ParseVault(r, VaultOptions{}, &c)
+	items, skipped, failed := c.Items, c.Skipped, c.Failed
+
+	byTitle := map[string]Item{}
+	for _, item := range items {
+		byTitle[item.Note.Title] = item
+	}
+	assert.Len(t, items, 3)
+	assert.Equal(t, DefaultTopic, byTitle["Inbox note"].TopicTitle)
+	assert.Equal(t, "Work", byTitle["The plan"].TopicTitle)
+	assert.Equal(t, "Work - Projects", byTitle["Alpha"].TopicTitle)
+	assert.Equal(t, modified, byTitle["Alpha"].Note.CreatedAt)
+	assert.Equal(t, modified, byTitle["Alpha"].Note.UpdatedAt)
+
+	assert.ElementsMatch(t, []string{"Work/diagram.png", ".obsidian/workspace.json"}, skipped)
+	assert.Len(t, failed, 2)
+}
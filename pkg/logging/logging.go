@@ -0,0 +1,59 @@
+// Package logging sets up structured JSON logging and carries a request's
+// logger through its context, so that every layer logs with the request ID
+// and user attached.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// New returns a logger writing JSON lines to w at level, one of debug, info,
+// warn or error. Unknown levels log at info.
+func New(w io.Writer, level string) *slog.Logger {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		l = slog.LevelInfo
+	}
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: l}))
+}
+
+type contextKey struct{}
+
+// entry is the logger of a request. Attributes learned while the request is
+// handled, such as its user, are added to it.
+type entry struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+// NewContext returns a context carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, &entry{logger: logger})
+}
+
+// FromContext returns the logger carried by ctx, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	e, ok := ctx.Value(contextKey{}).(*entry)
+	if !ok {
+		return slog.Default()
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.logger
+}
+
+// AddAttrs adds attributes to the logger carried by ctx, for everything
+// logged afterwards. Without a logger in ctx it does nothing.
+func AddAttrs(ctx context.Context, attrs ...any) {
+	e, ok := ctx.Value(contextKey{}).(*entry)
+	if !ok {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.logger = e.logger.With(attrs...)
+}
@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_Levels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "warn")
+	logger.Info("hidden")
+	logger.Warn("shown", "n", 1)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "shown", line["msg"])
+	assert.Equal(t, "WARN", line["level"])
+	assert.Equal(t, 1.0, line["n"])
+
+	assert.True(t, New(&buf, "nonsense").Enabled(context.Background(), slog.LevelInfo))
+	assert.False(t, New(&buf, "nonsense").Enabled(context.Background(), slog.LevelDebug))
+}
+
+func TestContext(t *testing.T) {
+	assert.Equal(t, slog.Default(), FromContext(context.Background()))
+	AddAttrs(context.Background(), "ignored", true)
+
+	var buf bytes.Buffer
+	ctx := NewContext(context.Background(), New(&buf, "info").With("requestId", "r1"))
+	AddAttrs(ctx, "user", "u1")
+	FromContext(ctx).Info("stored")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "r1", line["requestId"])
+	assert.Equal(t, "u1", line["user"])
+}
@@ -0,0 +1,154 @@
+package notes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// ErrUserNotFound is returned by the admin functions for unknown users.
+var ErrUserNotFound = &Error{ErrNotFound, "user not found"}
+
+// ListUsers returns every user, ordered by email.
+func (s *Store) ListUsers(ctx context.Context) ([]User, error) {
+	keyCond := expression.Key(pk).Equal(expression.Value(userKey("").Hash.Value))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, fmt.Errorf("expression builder: %w", err)
+	}
+
+	paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		TableName:                 aws.String(s.table),
+	})
+
+	var users = []User{}
+	for paginator.HasMorePages() {
+		resp, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("query, %w", err)
+		}
+
+		var page = []User{}
+		err = attributevalue.UnmarshalListOfMaps(resp.Items, &page)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal list of maps, %w", err)
+		}
+		users = append(users, page...)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Email < users[j].Email
+	})
+
+	return users, nil
+}
+
+// DeleteUser removes a user and everything they own: topics and their
+// reminders, templates, journal settings and calendar feed token.
+func (s *Store) DeleteUser(ctx context.Context, userID string) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get user by id, %w", err)
+	}
+	if user == nil {
+		return fmt.Errorf("user %q: %w", userID, ErrUserNotFound)
+	}
+
+	topics, err := s.getTopics(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get topics, %w", err)
+	}
+	for _, topic := range topics {
+		err = s.DeleteTopic(ctx, userID, topic.Title)
+		if err != nil {
+			return fmt.Errorf("delete topic %q, %w", topic.Title, err)
+		}
+	}
+
+	templates, err := s.GetTemplates(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get templates, %w", err)
+	}
+	for _, template := range templates {
+		err = s.DeleteTemplate(ctx, userID, template.Name)
+		if err != nil {
+			return fmt.Errorf("delete template %q, %w", template.Name, err)
+		}
+	}
+
+	resp, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key:       getKey(userCalendarKey(userID)),
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo get item, %w", err)
+	}
+	// Users not yet moved by migration 3 are still keyed by email.
+	keys := []DBKey{journalKey(userID), userCalendarKey(userID), legacyUserKey(user.Email), userKey(userID)}
+	if resp.Item != nil {
+		var token CalendarToken
+		err = attributevalue.UnmarshalMap(resp.Item, &token)
+		if err != nil {
+			return fmt.Errorf("unmarshal map, %w", err)
+		}
+		keys = append(keys, calendarTokenKey(token.Token))
+	}
+
+	// The email claim may belong to another user whose email only differs
+	// in case and who was migrated first.
+	cond := expression.Name("UserID").Equal(expression.Value(userID))
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		return fmt.Errorf("expression builder: %w", err)
+	}
+	_, err = s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       getKey(emailKey(user.Email)),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil && !isConditionalCheckFailed(err) {
+		return fmt.Errorf("dynamo delete item, %w", err)
+	}
+
+	// The user item goes last, so a failed run can be repeated.
+	for _, key := range keys {
+		_, err = s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(s.table),
+			Key:       getKey(key),
+		})
+		if err != nil {
+			return fmt.Errorf("dynamo delete item, %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ReindexUser rewrites every topic of the user, recomputing the fields
+// derived from note content, filling in missing positions and rebuilding the
+// reminder index. It returns the number of topics rewritten.
+func (s *Store) ReindexUser(ctx context.Context, userID string) (int, error) {
+	topics, err := s.getTopics(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("get topics, %w", err)
+	}
+
+	for i, topic := range topics {
+		err = s.RestoreTopic(ctx, userID, topic)
+		if err != nil {
+			return i, fmt.Errorf("reindex topic %q, %w", topic.Title, err)
+		}
+	}
+
+	return len(topics), nil
+}
@@ -0,0 +1,64 @@
+package notes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
+
+// ErrTopicArchived is returned when adding notes to an archived topic.
+// Archived topics are read-only until they are unarchived.
+var ErrTopicArchived = &Error{ErrConflict, "topic is archived"}
+
+// GetArchivedForUser returns the user's archived topics.
+func (s *Store) GetArchivedForUser(ctx context.Context, userID string) ([]Topic, error) {
+	topics, err := s.getTopics(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterArchived(topics, true), nil
+}
+
+// GetAllTopicsForUser returns all of the user's topics, archived or not.
+func (s *Store) GetAllTopicsForUser(ctx context.Context, userID string) ([]Topic, error) {
+	return s.getTopics(ctx, userID)
+}
+
+// ArchiveTopic hides a topic from the default listings without deleting it.
+func (s *Store) ArchiveTopic(ctx context.Context, userID, title string) error {
+	update := expression.Set(expression.Name("Archived"), expression.Value(true)).
+		Set(expression.Name("ArchivedAt"), expression.Value(time.Now().UTC()))
+
+	err := s.updateTopic(ctx, userID, title, update)
+	if err != nil {
+		return fmt.Errorf("archive topic %q, %w", title, err)
+	}
+
+	return nil
+}
+
+// UnarchiveTopic returns an archived topic to the default listings.
+func (s *Store) UnarchiveTopic(ctx context.Context, userID, title string) error {
+	update := expression.Set(expression.Name("Archived"), expression.Value(false)).
+		Remove(expression.Name("ArchivedAt"))
+
+	err := s.updateTopic(ctx, userID, title, update)
+	if err != nil {
+		return fmt.Errorf("unarchive topic %q, %w", title, err)
+	}
+
+	return nil
+}
+
+func filterArchived(topics []Topic, archived bool) []Topic {
+	filtered := []Topic{}
+	for _, topic := range topics {
+		if topic.Archived == archived {
+			filtered = append(filtered, topic)
+		}
+	}
+	return filtered
+}
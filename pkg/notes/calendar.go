@@ -0,0 +1,152 @@
+package notes
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const calendarTokenSort = "token"
+
+// CalendarToken grants read access to a user's calendar feed. Tokens are
+// stored twice: under the token, to authenticate feed requests, and under
+// the user, so creating a new token revokes the old one.
+type CalendarToken struct {
+	Token     string
+	UserID    string
+	CreatedAt time.Time
+}
+
+// ErrCalendarTokenNotFound is returned for unknown or revoked feed tokens.
+var ErrCalendarTokenNotFound = &Error{ErrNotFound, "calendar token not found"}
+
+func calendarTokenKey(token string) DBKey {
+	return DBKey{
+		Hash: KeyValue{
+			Key:   pk,
+			Value: calendarPrefix,
+		},
+		Sort: KeyValue{
+			Key:   sk,
+			Value: token,
+		},
+	}
+}
+
+func userCalendarKey(userID string) DBKey {
+	return DBKey{
+		Hash: KeyValue{
+			Key:   pk,
+			Value: fmt.Sprintf("%s#%s", calendarPrefix, userID),
+		},
+		Sort: KeyValue{
+			Key:   sk,
+			Value: calendarTokenSort,
+		},
+	}
+}
+
+// CreateCalendarToken issues a new feed token for the user, revoking any
+// previous one.
+func (s *Store) CreateCalendarToken(ctx context.Context, userID string) (*CalendarToken, error) {
+	random := make([]byte, 32)
+	_, err := rand.Read(random)
+	if err != nil {
+		return nil, fmt.Errorf("random token, %w", err)
+	}
+
+	token := CalendarToken{
+		Token:     base64.RawURLEncoding.EncodeToString(random),
+		UserID:    userID,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	item, err := attributevalue.MarshalMap(token)
+	if err != nil {
+		return nil, fmt.Errorf("dynamo marshal map, %w", err)
+	}
+
+	tokenItem := map[string]types.AttributeValue{}
+	userItem := map[string]types.AttributeValue{}
+	for name, value := range item {
+		tokenItem[name] = value
+		userItem[name] = value
+	}
+	for name, value := range getKey(calendarTokenKey(token.Token)) {
+		tokenItem[name] = value
+	}
+	for name, value := range getKey(userCalendarKey(userID)) {
+		userItem[name] = value
+	}
+
+	// Replace the user's pointer first and read back the old token, so the
+	// old token can be deleted.
+	resp, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:    aws.String(s.table),
+		Item:         userItem,
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dynamo put item, %w", err)
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      tokenItem,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dynamo put item, %w", err)
+	}
+
+	if resp.Attributes != nil {
+		var old = CalendarToken{}
+		err = attributevalue.UnmarshalMap(resp.Attributes, &old)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal map, %w", err)
+		}
+
+		_, err = s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(s.table),
+			Key:       getKey(calendarTokenKey(old.Token)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("dynamo delete item, %w", err)
+		}
+	}
+
+	return &token, nil
+}
+
+// GetCalendarUser returns the ID of the user a feed token belongs to.
+func (s *Store) GetCalendarUser(ctx context.Context, token string) (string, error) {
+	if token == "" {
+		return "", ErrCalendarTokenNotFound
+	}
+
+	resp, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key:       getKey(calendarTokenKey(token)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("dynamo get item, %w", err)
+	}
+	if resp.Item == nil {
+		return "", ErrCalendarTokenNotFound
+	}
+
+	var calendarToken = CalendarToken{}
+
+	err = attributevalue.UnmarshalMap(resp.Item, &calendarToken)
+	if err != nil {
+		return "", fmt.Errorf("unmarshal map, %w", err)
+	}
+
+	return calendarToken.UserID, nil
+}
@@ -0,0 +1,47 @@
+package notes
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of domain errors. Every error this package returns because of what
+// was asked, rather than because the store failed, wraps one of them, so
+// callers can check the kind with errors.Is.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("invalid")
+	ErrForbidden  = errors.New("forbidden")
+)
+
+// Error is a domain error of a Kind. The specific errors, such as
+// ErrTopicNotFound, are *Error values and are wrapped with details, e.g.
+// fmt.Errorf("topic %q: %w", title, ErrTopicNotFound).
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+var (
+	// ErrTopicNotFound is returned when the user has no topic with the title.
+	ErrTopicNotFound = &Error{ErrNotFound, "topic not found"}
+	// ErrNoteNotFound is returned when the topic has no note with the title.
+	ErrNoteNotFound = &Error{ErrNotFound, "note not found"}
+	// ErrNoteExists is returned when a note would take the title of another
+	// note in the topic.
+	ErrNoteExists = &Error{ErrConflict, "note already exists"}
+)
+
+// invalidf returns a validation error with a formatted message.
+func invalidf(format string, args ...any) error {
+	return &Error{ErrValidation, fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,95 @@
+package notes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
+
+// Favorites collects everything a user has marked as a favorite. Topics are
+// returned without their notes.
+type Favorites struct {
+	Topics []Topic
+	Notes  []FavoriteNote
+}
+
+type FavoriteNote struct {
+	TopicTitle string
+	Note       Note
+}
+
+// SetTopicPinned pins or unpins a topic. Pinned topics are listed first.
+func (s *Store) SetTopicPinned(ctx context.Context, userID, title string, pinned bool) error {
+	return s.setTopicFlag(ctx, userID, title, "Pinned", pinned)
+}
+
+// SetTopicFavorite marks or unmarks a topic as a favorite.
+func (s *Store) SetTopicFavorite(ctx context.Context, userID, title string, favorite bool) error {
+	return s.setTopicFlag(ctx, userID, title, "Favorite", favorite)
+}
+
+// SetNotePinned pins or unpins a note. Pinned notes are listed first within
+// their topic.
+func (s *Store) SetNotePinned(ctx context.Context, userID, title, noteTitle string, pinned bool) error {
+	return s.setNoteFlag(ctx, userID, title, noteTitle, func(note *Note) {
+		note.Pinned = pinned
+	})
+}
+
+// SetNoteFavorite marks or unmarks a note as a favorite.
+func (s *Store) SetNoteFavorite(ctx context.Context, userID, title, noteTitle string, favorite bool) error {
+	return s.setNoteFlag(ctx, userID, title, noteTitle, func(note *Note) {
+		note.Favorite = favorite
+	})
+}
+
+// GetFavorites lists the user's favorite topics and notes across all topics,
+// pinned items first and otherwise in manual order.
+func (s *Store) GetFavorites(ctx context.Context, userID string) (*Favorites, error) {
+	topics, err := s.GetAllForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get all for user, %w", err)
+	}
+
+	favorites := Favorites{
+		Topics: []Topic{},
+		Notes:  []FavoriteNote{},
+	}
+
+	for _, topic := range topics {
+		for _, note := range topic.Notes {
+			if note.Favorite {
+				favorites.Notes = append(favorites.Notes, FavoriteNote{TopicTitle: topic.Title, Note: note})
+			}
+		}
+
+		if topic.Favorite {
+			topic.Notes = nil
+			favorites.Topics = append(favorites.Topics, topic)
+		}
+	}
+
+	sort.SliceStable(favorites.Notes, func(i, j int) bool {
+		return favorites.Notes[i].Note.Pinned && !favorites.Notes[j].Note.Pinned
+	})
+
+	return &favorites, nil
+}
+
+func (s *Store) setTopicFlag(ctx context.Context, userID, title, name string, value bool) error {
+	err := s.updateTopic(ctx, userID, title, expression.Set(expression.Name(name), expression.Value(value)))
+	if err != nil {
+		return fmt.Errorf("set %s on topic %q, %w", name, title, err)
+	}
+
+	return nil
+}
+
+func (s *Store) setNoteFlag(ctx context.Context, userID, title, noteTitle string, set func(note *Note)) error {
+	return s.updateNote(ctx, userID, title, noteTitle, func(note *Note) error {
+		set(note)
+		return nil
+	})
+}
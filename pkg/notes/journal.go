@@ -0,0 +1,347 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	// Lambda images do not ship a zoneinfo database.
+	_ "time/tzdata"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// journalDateLayout is both the title of a journal entry and the format of
+// dates accepted by the journal functions.
+const journalDateLayout = "2006-01-02"
+
+const journalSettings = "settings"
+
+// Journal is a user's journal configuration. Each day gets one note, titled
+// with its date, in Topic.
+type Journal struct {
+	Topic    string
+	TimeZone string
+}
+
+// ErrJournalNotConfigured is returned by the journal functions before
+// SetJournal has been called for the user.
+var ErrJournalNotConfigured = &Error{ErrNotFound, "journal not configured"}
+
+// ErrJournalEntryMissing is returned when the journal topic records an entry
+// for a day but holds no note with its title.
+var ErrJournalEntryMissing = &Error{ErrConflict, "journal entry is recorded but missing"}
+
+func journalKey(userID string) DBKey {
+	return DBKey{
+		Hash: KeyValue{
+			Key:   pk,
+			Value: fmt.Sprintf("%s#%s", journalPrefix, userID),
+		},
+		Sort: KeyValue{
+			Key:   sk,
+			Value: journalSettings,
+		},
+	}
+}
+
+// stringSet marshals as a DynamoDB string set rather than a list.
+type stringSet []string
+
+func (s stringSet) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberSS{Value: s}, nil
+}
+
+// SetJournal designates the topic that holds the user's journal and the time
+// zone used to decide what "today" is. The topic is created if needed.
+func (s *Store) SetJournal(ctx context.Context, userID string, journal Journal) error {
+	if _, err := time.LoadLocation(journal.TimeZone); err != nil {
+		return invalidf("time zone %q, %s", journal.TimeZone, err)
+	}
+
+	_, err := s.EnsureTopic(ctx, userID, journal.Topic)
+	if err != nil {
+		return fmt.Errorf("ensure topic, %w", err)
+	}
+
+	item, err := attributevalue.MarshalMap(journal)
+	if err != nil {
+		return fmt.Errorf("dynamo marshal map, %w", err)
+	}
+	for name, value := range getKey(journalKey(userID)) {
+		item[name] = value
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo put item, %w", err)
+	}
+
+	return nil
+}
+
+// GetJournal returns the user's journal configuration, or
+// ErrJournalNotConfigured.
+func (s *Store) GetJournal(ctx context.Context, userID string) (*Journal, error) {
+	resp, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key:       getKey(journalKey(userID)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dynamo get item, %w", err)
+	}
+	if resp.Item == nil {
+		return nil, ErrJournalNotConfigured
+	}
+
+	var journal = Journal{}
+
+	err = attributevalue.UnmarshalMap(resp.Item, &journal)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal map, %w", err)
+	}
+
+	return &journal, nil
+}
+
+// GetJournalEntry returns the journal entry for date, creating it if it does
+// not exist yet. An empty date means today in the journal's time zone.
+// Creation is idempotent: concurrent calls for the same day produce one note.
+func (s *Store) GetJournalEntry(ctx context.Context, userID, date string) (*Note, error) {
+	journal, err := s.GetJournal(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := journalDate(time.Now(), journal.TimeZone, date)
+	if err != nil {
+		return nil, err
+	}
+
+	topic, err := s.GetUserTopicByTitle(ctx, userID, journal.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("get user topic by title, %w", err)
+	}
+	if note := FindNote(topic.Notes, day); note != nil {
+		return note, nil
+	}
+	if topic.Archived {
+		return nil, fmt.Errorf("topic %q: %w", journal.Topic, ErrTopicArchived)
+	}
+
+	note, err := newJournalEntry(day, topic.Notes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.appendJournalEntry(ctx, userID, journal.Topic, note)
+	if isConditionalCheckFailed(err) {
+		// Another request created the entry first.
+		topic, err = s.GetUserTopicByTitle(ctx, userID, journal.Topic)
+		if err != nil && !errors.Is(err, ErrTopicNotFound) {
+			return nil, fmt.Errorf("get user topic by title, %w", err)
+		}
+		if topic != nil {
+			if note := FindNote(topic.Notes, day); note != nil {
+				return note, nil
+			}
+		}
+		return nil, fmt.Errorf("journal entry %q: %w", day, ErrJournalEntryMissing)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+// GetJournalMonth lists the existing journal entries in month, given as
+// YYYY-MM, oldest first.
+func (s *Store) GetJournalMonth(ctx context.Context, userID, month string) ([]Note, error) {
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return nil, invalidf("month %q, expected YYYY-MM", month)
+	}
+
+	entries, err := s.journalEntries(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	inMonth := []Note{}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Title, month+"-") {
+			inMonth = append(inMonth, entry)
+		}
+	}
+
+	return inMonth, nil
+}
+
+// GetAdjacentJournalEntry returns the closest existing entry before (previous)
+// or after date. Days without an entry are skipped; nil means there is none.
+func (s *Store) GetAdjacentJournalEntry(ctx context.Context, userID, date string, previous bool) (*Note, error) {
+	journal, err := s.GetJournal(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := journalDate(time.Now(), journal.TimeZone, date)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.journalEntries(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Title < day {
+				return &entries[i], nil
+			}
+		}
+		return nil, nil
+	}
+
+	for i := range entries {
+		if entries[i].Title > day {
+			return &entries[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// journalDate resolves date, or today in the named time zone when date is
+// empty, to a journal entry title.
+func journalDate(now time.Time, timeZone, date string) (string, error) {
+	if date != "" {
+		day, err := time.Parse(journalDateLayout, date)
+		if err != nil {
+			return "", invalidf("date %q, expected YYYY-MM-DD", date)
+		}
+		return day.Format(journalDateLayout), nil
+	}
+
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return "", fmt.Errorf("time zone %q, %w", timeZone, err)
+	}
+
+	return now.In(location).Format(journalDateLayout), nil
+}
+
+// journalEntries returns the notes of the journal topic that are journal
+// entries, oldest first.
+func (s *Store) journalEntries(ctx context.Context, userID string) ([]Note, error) {
+	journal, err := s.GetJournal(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	topic, err := s.GetUserTopicByTitle(ctx, userID, journal.Topic)
+	if errors.Is(err, ErrTopicNotFound) {
+		return []Note{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get user topic by title, %w", err)
+	}
+
+	dates := map[string]bool{}
+	for _, date := range topic.JournalDates {
+		dates[date] = true
+	}
+
+	entries := []Note{}
+	for _, note := range topic.Notes {
+		if dates[note.Title] {
+			entries = append(entries, note)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Title < entries[j].Title
+	})
+
+	return entries, nil
+}
+
+func newJournalEntry(day string, existing []Note) (Note, error) {
+	date, err := time.Parse(journalDateLayout, day)
+	if err != nil {
+		return Note{}, err
+	}
+
+	position, err := lastNotePosition(existing)
+	if err != nil {
+		return Note{}, fmt.Errorf("last note position, %w", err)
+	}
+
+	now := time.Now().UTC()
+	note := Note{
+		Title:     day,
+		Content:   fmt.Sprintf("# %s\n\n", date.Format("Monday, 2 January 2006")),
+		Position:  position,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	indexNote(&note)
+
+	return note, nil
+}
+
+// appendJournalEntry adds the note to the topic only if no entry for its date
+// has been recorded in the topic's JournalDates set.
+func (s *Store) appendJournalEntry(ctx context.Context, userID, title string, note Note) error {
+	notesName := expression.Name("Notes")
+	datesName := expression.Name("JournalDates")
+
+	update := expression.Set(notesName, expression.ListAppend(
+		expression.IfNotExists(notesName, expression.Value([]Note{})),
+		expression.Value([]Note{note}),
+	)).
+		Set(expression.Name("UpdatedAt"), expression.Value(note.CreatedAt)).
+		Add(datesName, expression.Value(stringSet{note.Title}))
+	cond := expression.AttributeExists(expression.Name(pk)).
+		And(expression.Not(expression.Contains(datesName, note.Title)))
+
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(cond).Build()
+	if err != nil {
+		return fmt.Errorf("expression builder: %w", err)
+	}
+
+	_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       getTopicKey(userID, title),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo update item, %w", err)
+	}
+
+	return nil
+}
+
+// forgetJournalDate drops title from the topic's journal dates, once the
+// note with that title is deleted or renamed, so the day can get a new entry.
+func (t *Topic) forgetJournalDate(title string) {
+	for i, date := range t.JournalDates {
+		if date == title {
+			t.JournalDates = append(t.JournalDates[:i], t.JournalDates[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package notes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalDate(t *testing.T) {
+	now := time.Date(2023, 3, 31, 23, 30, 0, 0, time.UTC)
+
+	day, err := journalDate(now, "UTC", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-03-31", day)
+
+	day, err = journalDate(now, "Europe/Amsterdam", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-04-01", day)
+
+	day, err = journalDate(now, "America/Los_Angeles", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-03-31", day)
+
+	day, err = journalDate(now, "Europe/Amsterdam", "2022-12-25")
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-12-25", day)
+
+	_, err = journalDate(now, "Europe/Amsterdam", "25/12/2022")
+	assert.Error(t, err)
+
+	_, err = journalDate(now, "Mars/Olympus", "")
+	assert.Error(t, err)
+}
+
+func TestForgetJournalDate(t *testing.T) {
+	topic := Topic{JournalDates: []string{"2023-03-30", "2023-03-31"}}
+
+	topic.forgetJournalDate("2023-03-30")
+	assert.Equal(t, []string{"2023-03-31"}, topic.JournalDates)
+
+	topic.forgetJournalDate("Ideas")
+	assert.Equal(t, []string{"2023-03-31"}, topic.JournalDates)
+}
@@ -0,0 +1,326 @@
+package notes
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxTransactItems is the most items DynamoDB writes in one transaction.
+const maxTransactItems = 100
+
+// ErrTopicChanged is returned when a topic was changed between reading and
+// writing it. Retrying reads the new version.
+var ErrTopicChanged = &Error{ErrConflict, "topic changed, try again"}
+
+// Link is an outgoing wiki link parsed from a note's content. [[Note Title]]
+// leaves Topic empty and resolves within the note's own topic first, then
+// across all topics. [[topic/note]] names the topic explicitly.
+type Link struct {
+	Topic string `dynamodbav:",omitempty"`
+	Note  string
+}
+
+// NoteRef identifies a note by its topic and title.
+type NoteRef struct {
+	TopicTitle string
+	NoteTitle  string
+}
+
+// BrokenLink is a link whose target note does not exist.
+type BrokenLink struct {
+	Source NoteRef
+	Link   Link
+}
+
+var wikiLinkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+)(\|[^\[\]\n]*)?\]\]`)
+
+// ParseLinks returns the distinct wiki links in markdown content, in order of
+// first appearance. Links inside fenced code blocks and inline code are ignored.
+func ParseLinks(content string) []Link {
+	links := []Link{}
+	seen := map[Link]bool{}
+
+	eachProseLine(content, func(line string) {
+		for _, match := range wikiLinkPattern.FindAllStringSubmatch(stripInlineCode(line), -1) {
+			link := parseLinkTarget(match[1])
+			if link.Note == "" || seen[link] {
+				continue
+			}
+			seen[link] = true
+			links = append(links, link)
+		}
+	})
+
+	return links
+}
+
+func parseLinkTarget(target string) Link {
+	target = strings.TrimSpace(target)
+	if topic, note, ok := strings.Cut(target, "/"); ok {
+		return Link{Topic: strings.TrimSpace(topic), Note: strings.TrimSpace(note)}
+	}
+	return Link{Note: target}
+}
+
+func (l Link) String() string {
+	if l.Topic == "" {
+		return l.Note
+	}
+	return l.Topic + "/" + l.Note
+}
+
+// eachProseLine calls fn for every line of content outside fenced code blocks.
+func eachProseLine(content string, fn func(line string)) {
+	fenced := false
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if !fenced {
+			fn(line)
+		}
+	}
+}
+
+var inlineCodePattern = regexp.MustCompile("`[^`]*`")
+
+// stripInlineCode blanks out inline code spans while keeping the line length.
+func stripInlineCode(line string) string {
+	return inlineCodePattern.ReplaceAllStringFunc(line, func(code string) string {
+		return strings.Repeat(" ", len(code))
+	})
+}
+
+// resolveLink finds the note a link points to. from is the title of the topic
+// containing the link.
+func resolveLink(topics []Topic, from string, link Link) (NoteRef, bool) {
+	if link.Topic != "" {
+		for _, topic := range topics {
+			if topic.Title == link.Topic && topic.HasNote(link.Note) {
+				return NoteRef{TopicTitle: topic.Title, NoteTitle: link.Note}, true
+			}
+		}
+		// A note title may itself contain a slash.
+		return resolveLink(topics, from, Link{Note: link.String()})
+	}
+
+	for _, topic := range topics {
+		if topic.Title == from && topic.HasNote(link.Note) {
+			return NoteRef{TopicTitle: topic.Title, NoteTitle: link.Note}, true
+		}
+	}
+	for _, topic := range topics {
+		if topic.HasNote(link.Note) {
+			return NoteRef{TopicTitle: topic.Title, NoteTitle: link.Note}, true
+		}
+	}
+
+	return NoteRef{}, false
+}
+
+// rewriteLinks replaces every link in content for which rewrite returns true
+// with the link it returns, keeping any alias.
+func rewriteLinks(content string, rewrite func(link Link) (Link, bool)) string {
+	fenced := false
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+
+		masked := stripInlineCode(line)
+		var out strings.Builder
+		last := 0
+		for _, loc := range wikiLinkPattern.FindAllStringSubmatchIndex(masked, -1) {
+			link, ok := rewrite(parseLinkTarget(line[loc[2]:loc[3]]))
+			if !ok {
+				continue
+			}
+
+			out.WriteString(line[last:loc[0]])
+			out.WriteString("[[" + link.String())
+			if loc[4] >= 0 {
+				out.WriteString(line[loc[4]:loc[5]])
+			}
+			out.WriteString("]]")
+			last = loc[1]
+		}
+		if last > 0 {
+			out.WriteString(line[last:])
+			lines[i] = out.String()
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// renamedLink returns link pointing at newTitle if, resolved from the topic
+// titled from, it points at target. The topic prefix is kept when the link
+// names the topic, and dropped when the prefix was part of a note title
+// containing a slash.
+func renamedLink(topics []Topic, from string, link Link, target NoteRef, newTitle string) (Link, bool) {
+	ref, ok := resolveLink(topics, from, link)
+	if !ok || ref != target {
+		return link, false
+	}
+	if link.Topic != "" && link.Topic == ref.TopicTitle && link.Note == ref.NoteTitle {
+		return Link{Topic: link.Topic, Note: newTitle}, true
+	}
+	return Link{Note: newTitle}, true
+}
+
+// GetBacklinks lists the notes that link to the given note.
+func (s *Store) GetBacklinks(ctx context.Context, userID, title, noteTitle string) ([]NoteRef, error) {
+	topics, err := s.getTopics(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get topics, %w", err)
+	}
+
+	target := NoteRef{TopicTitle: title, NoteTitle: noteTitle}
+	backlinks := []NoteRef{}
+	for _, topic := range topics {
+		for _, note := range topic.Notes {
+			for _, link := range note.Links {
+				if ref, ok := resolveLink(topics, topic.Title, link); ok && ref == target {
+					backlinks = append(backlinks, NoteRef{TopicTitle: topic.Title, NoteTitle: note.Title})
+					break
+				}
+			}
+		}
+	}
+
+	return backlinks, nil
+}
+
+// GetBrokenLinks lists every link in the user's notes that does not resolve to
+// an existing note.
+func (s *Store) GetBrokenLinks(ctx context.Context, userID string) ([]BrokenLink, error) {
+	topics, err := s.getTopics(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get topics, %w", err)
+	}
+
+	broken := []BrokenLink{}
+	for _, topic := range topics {
+		for _, note := range topic.Notes {
+			for _, link := range note.Links {
+				if _, ok := resolveLink(topics, topic.Title, link); !ok {
+					broken = append(broken, BrokenLink{
+						Source: NoteRef{TopicTitle: topic.Title, NoteTitle: note.Title},
+						Link:   link,
+					})
+				}
+			}
+		}
+	}
+
+	return broken, nil
+}
+
+// RenameNote changes a note's title and rewrites every link that pointed at it
+// under its old title, across all of the user's topics. The topics are written
+// in one transaction, which fails with ErrTopicChanged if any of them was
+// changed after it was read.
+func (s *Store) RenameNote(ctx context.Context, userID, title, noteTitle, newTitle string) error {
+	topics, err := s.getTopics(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("get topics, %w", err)
+	}
+
+	var renamed *Topic
+	for i := range topics {
+		if topics[i].Title == title {
+			renamed = &topics[i]
+		}
+	}
+	if renamed == nil {
+		return fmt.Errorf("topic %q: %w", title, ErrTopicNotFound)
+	}
+	if !renamed.HasNote(noteTitle) {
+		return fmt.Errorf("note %q in topic %q: %w", noteTitle, title, ErrNoteNotFound)
+	}
+	if noteTitle == newTitle {
+		return nil
+	}
+	if renamed.HasNote(newTitle) {
+		return fmt.Errorf("note %q in topic %q: %w", newTitle, title, ErrNoteExists)
+	}
+
+	target := NoteRef{TopicTitle: title, NoteTitle: noteTitle}
+	now := time.Now().UTC()
+
+	// Resolve links against the titles as they were before the rename.
+	changed := map[string]bool{title: true}
+	for i := range topics {
+		for j := range topics[i].Notes {
+			note := &topics[i].Notes[j]
+			from := topics[i].Title
+			content := rewriteLinks(note.Content, func(link Link) (Link, bool) {
+				return renamedLink(topics, from, link, target, newTitle)
+			})
+			if content != note.Content {
+				note.Content = content
+				note.UpdatedAt = now
+				changed[from] = true
+			}
+		}
+	}
+
+	var before, after Note
+	for i := range renamed.Notes {
+		if renamed.Notes[i].Title == noteTitle {
+			before = renamed.Notes[i]
+			renamed.Notes[i].Title = newTitle
+			renamed.Notes[i].UpdatedAt = now
+			after = renamed.Notes[i]
+		}
+	}
+	renamed.forgetJournalDate(noteTitle)
+
+	if len(changed) > maxTransactItems {
+		return invalidf("note %q is linked from %d topics, at most %d can be rewritten at once", noteTitle, len(changed)-1, maxTransactItems-1)
+	}
+
+	var items []types.TransactWriteItem
+	for i := range topics {
+		if !changed[topics[i].Title] {
+			continue
+		}
+		for j := range topics[i].Notes {
+			indexNote(&topics[i].Notes[j])
+		}
+
+		// Only write the topic as it was read.
+		cond := expression.Name("UpdatedAt").Equal(expression.Value(topics[i].UpdatedAt))
+		topics[i].UpdatedAt = now
+		put, err := s.topicPut(userID, topics[i], &cond)
+		if err != nil {
+			return fmt.Errorf("topic %q, %w", topics[i].Title, err)
+		}
+		items = append(items, types.TransactWriteItem{Put: put})
+	}
+
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if isTransactionConflict(err) {
+		return fmt.Errorf("rename note %q in topic %q: %w", noteTitle, title, ErrTopicChanged)
+	}
+	if err != nil {
+		return fmt.Errorf("dynamo transact write items, %w", err)
+	}
+
+	return s.syncReminder(ctx, userID, title, &before, title, &after)
+}
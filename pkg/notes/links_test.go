@@ -0,0 +1,77 @@
+package notes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLinks(t *testing.T) {
+	content := "See [[Roadmap]] and [[Ideas/Side project|the side project]].\n" +
+		"Again [[Roadmap]] and `[[not a link]]`.\n" +
+		"```\n[[also not a link]]\n```\n" +
+		"[[ Retro ]]"
+
+	links := ParseLinks(content)
+
+	assert.Equal(t, []Link{
+		{Note: "Roadmap"},
+		{Topic: "Ideas", Note: "Side project"},
+		{Note: "Retro"},
+	}, links)
+}
+
+func TestResolveLink(t *testing.T) {
+	topics := []Topic{
+		{Title: "Projects", Notes: []Note{{Title: "Roadmap"}, {Title: "a/b"}}},
+		{Title: "Ideas", Notes: []Note{{Title: "Roadmap"}, {Title: "Side project"}}},
+	}
+
+	ref, ok := resolveLink(topics, "Ideas", Link{Note: "Roadmap"})
+	assert.True(t, ok)
+	assert.Equal(t, NoteRef{TopicTitle: "Ideas", NoteTitle: "Roadmap"}, ref)
+
+	ref, ok = resolveLink(topics, "Projects", Link{Note: "Side project"})
+	assert.True(t, ok)
+	assert.Equal(t, NoteRef{TopicTitle: "Ideas", NoteTitle: "Side project"}, ref)
+
+	ref, ok = resolveLink(topics, "Ideas", Link{Topic: "a", Note: "b"})
+	assert.True(t, ok)
+	assert.Equal(t, NoteRef{TopicTitle: "Projects", NoteTitle: "a/b"}, ref)
+
+	_, ok = resolveLink(topics, "Ideas", Link{Topic: "Projects", Note: "Side project"})
+	assert.False(t, ok)
+}
+
+func TestRewriteLinks(t *testing.T) {
+	content := "[[Roadmap]], [[Projects/Roadmap|plan]], [[Other]] and `[[Roadmap]]`"
+
+	rewritten := rewriteLinks(content, func(link Link) (Link, bool) {
+		if link.Note != "Roadmap" {
+			return link, false
+		}
+		link.Note = "Plan 2024"
+		return link, true
+	})
+
+	assert.Equal(t, "[[Plan 2024]], [[Projects/Plan 2024|plan]], [[Other]] and `[[Roadmap]]`", rewritten)
+}
+
+func TestRenamedLink(t *testing.T) {
+	topics := []Topic{
+		{Title: "Projects", Notes: []Note{{Title: "Roadmap"}, {Title: "a/b"}}},
+		{Title: "Ideas", Notes: []Note{{Title: "Roadmap"}}},
+	}
+	rename := func(content string, target NoteRef) string {
+		return rewriteLinks(content, func(link Link) (Link, bool) {
+			return renamedLink(topics, "Ideas", link, target, "Plan 2024")
+		})
+	}
+
+	roadmap := NoteRef{TopicTitle: "Projects", NoteTitle: "Roadmap"}
+	assert.Equal(t, "[[Roadmap]], [[Projects/Plan 2024|plan]]", rename("[[Roadmap]], [[Projects/Roadmap|plan]]", roadmap))
+
+	// [[a/b]] resolves to the note titled "a/b", not to a topic "a".
+	slashed := NoteRef{TopicTitle: "Projects", NoteTitle: "a/b"}
+	assert.Equal(t, "[[Plan 2024|b]], [[Projects/Plan 2024]]", rename("[[a/b|b]], [[Projects/a/b]]", slashed))
+}
@@ -0,0 +1,368 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// defaultMigrationBatch is how many items a migration scans per batch.
+const defaultMigrationBatch = 100
+
+// Migration rewrites stored items into a newer shape. A migration scans the
+// items matching Filter in batches and hands each batch to Apply. Progress is
+// recorded after every batch, so an interrupted run resumes where it stopped;
+// Apply may therefore see a batch twice and must be idempotent.
+type Migration struct {
+	Version int
+	Name    string
+	// Filter selects the items to migrate, e.g. begins_with(PK, "topic#").
+	Filter func() expression.ConditionBuilder
+	Apply  func(s *Store, ctx context.Context, items []map[string]types.AttributeValue) error
+}
+
+// MigrationRecord is the stored state of a migration. A record without
+// AppliedAt is in progress; Cursor is where its next batch starts.
+type MigrationRecord struct {
+	Version   int
+	Name      string
+	Cursor    map[string]string `dynamodbav:",omitempty"`
+	Items     int
+	StartedAt time.Time
+	AppliedAt *time.Time `dynamodbav:",omitempty"`
+}
+
+// MigrateOptions limits a run of Migrate.
+type MigrateOptions struct {
+	// BatchSize is the number of items scanned per batch.
+	BatchSize int32
+	// MaxBatches stops the run after that many batches, to stay within a
+	// time limit. Zero means no limit.
+	MaxBatches int
+}
+
+// ErrMigrationsPending is returned by Migrate when MaxBatches was reached
+// before every migration was applied. Run it again to continue.
+var ErrMigrationsPending = errors.New("migrations pending")
+
+// migrations are applied in Version order. Never change or reorder a
+// migration once released; add a new one instead.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "index users by ID",
+		Filter: func() expression.ConditionBuilder {
+			return expression.Name(pk).Equal(expression.Value(userPrefix)).
+				And(expression.AttributeNotExists(expression.Name(gsi1pk)))
+		},
+		Apply: (*Store).indexUsers,
+	},
+	{
+		Version: 2,
+		Name:    "reindex topics and give notes IDs",
+		Filter: func() expression.ConditionBuilder {
+			return expression.Name(pk).BeginsWith(topicPrefix + "#")
+		},
+		Apply: (*Store).reindexTopics,
+	},
+	{
+		Version: usersByIDVersion,
+		Name:    "key users by ID and claim their emails",
+		Filter: func() expression.ConditionBuilder {
+			return expression.Name(pk).Equal(expression.Value(userPrefix)).
+				And(expression.Name("ID").NotEqual(expression.Name(sk)))
+		},
+		Apply: (*Store).keyUsersByID,
+	},
+}
+
+func migrationKey(version int) DBKey {
+	return DBKey{
+		Hash: KeyValue{
+			Key:   pk,
+			Value: migrationPrefix,
+		},
+		Sort: KeyValue{
+			Key:   sk,
+			Value: fmt.Sprintf("%06d", version),
+		},
+	}
+}
+
+// GetMigrations returns the record of every known migration, in order.
+// Migrations that have not started have a zero StartedAt.
+func (s *Store) GetMigrations(ctx context.Context) ([]MigrationRecord, error) {
+	return s.migrationRecords(ctx)
+}
+
+// Migrate applies the pending migrations in order. Each migration is
+// recorded in the table when it starts, after every batch and when it
+// completes.
+func (s *Store) Migrate(ctx context.Context, opts MigrateOptions) ([]MigrationRecord, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultMigrationBatch
+	}
+
+	records, err := s.migrationRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	batches := 0
+	for i, migration := range sortedMigrations() {
+		record := &records[i]
+		for record.AppliedAt == nil {
+			if opts.MaxBatches > 0 && batches == opts.MaxBatches {
+				return records, ErrMigrationsPending
+			}
+
+			err = s.migrateBatch(ctx, migration, record, opts.BatchSize)
+			if err != nil {
+				return records, fmt.Errorf("migration %d %q, %w", migration.Version, migration.Name, err)
+			}
+			batches++
+		}
+	}
+
+	return records, nil
+}
+
+// migrateBatch applies migration to the next batch of items and records the
+// new position, or completion when the scan is done.
+func (s *Store) migrateBatch(ctx context.Context, migration Migration, record *MigrationRecord, limit int32) error {
+	if record.StartedAt.IsZero() {
+		record.StartedAt = time.Now().UTC()
+	}
+
+	expr, err := expression.NewBuilder().WithFilter(migration.Filter()).Build()
+	if err != nil {
+		return fmt.Errorf("expression builder: %w", err)
+	}
+
+	resp, err := s.client.Scan(ctx, &dynamodb.ScanInput{
+		TableName:                 aws.String(s.table),
+		FilterExpression:          expr.Filter(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ExclusiveStartKey:         fromCursor(record.Cursor),
+		Limit:                     aws.Int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("scan, %w", err)
+	}
+
+	if len(resp.Items) > 0 {
+		err = migration.Apply(s, ctx, resp.Items)
+		if err != nil {
+			return err
+		}
+	}
+
+	record.Items += len(resp.Items)
+	record.Cursor, err = toCursor(resp.LastEvaluatedKey)
+	if err != nil {
+		return err
+	}
+	if record.Cursor == nil {
+		now := time.Now().UTC()
+		record.AppliedAt = &now
+	}
+
+	return s.putMigrationRecord(ctx, *record)
+}
+
+func (s *Store) migrationRecords(ctx context.Context) ([]MigrationRecord, error) {
+	keyCond := expression.Key(pk).Equal(expression.Value(migrationPrefix))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, fmt.Errorf("expression builder: %w", err)
+	}
+
+	resp, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		TableName:                 aws.String(s.table),
+		ConsistentRead:            aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query, %w", err)
+	}
+
+	var stored = []MigrationRecord{}
+
+	err = attributevalue.UnmarshalListOfMaps(resp.Items, &stored)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal list of maps, %w", err)
+	}
+
+	return mergeRecords(sortedMigrations(), stored), nil
+}
+
+// mergeRecords returns one record per migration, taken from stored when it
+// exists.
+func mergeRecords(known []Migration, stored []MigrationRecord) []MigrationRecord {
+	byVersion := map[int]MigrationRecord{}
+	for _, record := range stored {
+		byVersion[record.Version] = record
+	}
+
+	records := make([]MigrationRecord, len(known))
+	for i, migration := range known {
+		record, ok := byVersion[migration.Version]
+		if !ok {
+			record = MigrationRecord{Version: migration.Version}
+		}
+		record.Name = migration.Name
+		records[i] = record
+	}
+	return records
+}
+
+func sortedMigrations() []Migration {
+	sorted := append([]Migration{}, migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	return sorted
+}
+
+// migrationApplied reports whether the migration with the version has
+// completed.
+func (s *Store) migrationApplied(ctx context.Context, version int) (bool, error) {
+	resp, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            getKey(migrationKey(version)),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return false, fmt.Errorf("dynamo get item, %w", err)
+	}
+	if resp.Item == nil {
+		return false, nil
+	}
+
+	var record MigrationRecord
+	err = attributevalue.UnmarshalMap(resp.Item, &record)
+	if err != nil {
+		return false, fmt.Errorf("unmarshal map, %w", err)
+	}
+
+	return record.AppliedAt != nil, nil
+}
+
+func (s *Store) putMigrationRecord(ctx context.Context, record MigrationRecord) error {
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return fmt.Errorf("dynamo marshal map, %w", err)
+	}
+	for name, value := range getKey(migrationKey(record.Version)) {
+		item[name] = value
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo put item, %w", err)
+	}
+
+	return nil
+}
+
+// toCursor stores a scan's LastEvaluatedKey. The table's keys are strings.
+func toCursor(key map[string]types.AttributeValue) (map[string]string, error) {
+	if len(key) == 0 {
+		return nil, nil
+	}
+
+	cursor := map[string]string{}
+	for name, value := range key {
+		s, ok := value.(*types.AttributeValueMemberS)
+		if !ok {
+			return nil, fmt.Errorf("cursor attribute %s is not a string", name)
+		}
+		cursor[name] = s.Value
+	}
+	return cursor, nil
+}
+
+func fromCursor(cursor map[string]string) map[string]types.AttributeValue {
+	if len(cursor) == 0 {
+		return nil
+	}
+
+	key := map[string]types.AttributeValue{}
+	for name, value := range cursor {
+		key[name] = &types.AttributeValueMemberS{Value: value}
+	}
+	return key
+}
+
+// indexUsers adds the GSI1 keys to user items.
+func (s *Store) indexUsers(ctx context.Context, items []map[string]types.AttributeValue) error {
+	for _, item := range items {
+		var user User
+		err := attributevalue.UnmarshalMap(item, &user)
+		if err != nil {
+			return fmt.Errorf("unmarshal map, %w", err)
+		}
+		if user.ID == "" {
+			continue
+		}
+
+		indexKey := userIndexKey(user.ID)
+		update := expression.Set(expression.Name(indexKey.Hash.Key), expression.Value(indexKey.Hash.Value)).
+			Set(expression.Name(indexKey.Sort.Key), expression.Value(indexKey.Sort.Value))
+		expr, err := expression.NewBuilder().WithUpdate(update).Build()
+		if err != nil {
+			return fmt.Errorf("expression builder: %w", err)
+		}
+
+		_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName:                 aws.String(s.table),
+			Key:                       map[string]types.AttributeValue{pk: item[pk], sk: item[sk]},
+			UpdateExpression:          expr.Update(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		})
+		if err != nil {
+			return fmt.Errorf("dynamo update item, %w", err)
+		}
+	}
+	return nil
+}
+
+// reindexTopics rewrites topic items through RestoreTopic, which fills in
+// positions, note IDs and derived fields and rebuilds reminders.
+func (s *Store) reindexTopics(ctx context.Context, items []map[string]types.AttributeValue) error {
+	for _, item := range items {
+		var topic Topic
+		err := attributevalue.UnmarshalMap(item, &topic)
+		if err != nil {
+			return fmt.Errorf("unmarshal map, %w", err)
+		}
+
+		hash, ok := item[pk].(*types.AttributeValueMemberS)
+		if !ok {
+			continue
+		}
+		userID := strings.TrimPrefix(hash.Value, topicPrefix+"#")
+
+		err = s.RestoreTopic(ctx, userID, topic)
+		if err != nil {
+			return fmt.Errorf("topic %q of user %s, %w", topic.Title, userID, err)
+		}
+	}
+	return nil
+}
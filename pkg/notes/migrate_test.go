@@ -0,0 +1,59 @@
+package notes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations_Versions(t *testing.T) {
+	seen := map[int]bool{}
+	for _, migration := range migrations {
+		assert.Greater(t, migration.Version, 0)
+		assert.False(t, seen[migration.Version], "duplicate version %d", migration.Version)
+		assert.NotEmpty(t, migration.Name)
+		assert.NotNil(t, migration.Filter)
+		assert.NotNil(t, migration.Apply)
+		seen[migration.Version] = true
+	}
+}
+
+func TestMergeRecords(t *testing.T) {
+	applied := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	known := []Migration{{Version: 1, Name: "one"}, {Version: 2, Name: "two"}, {Version: 3, Name: "three"}}
+	stored := []MigrationRecord{
+		{Version: 1, Name: "old name", Items: 10, AppliedAt: &applied},
+		{Version: 2, Items: 5, Cursor: map[string]string{"PK": "topic#u", "SK": "x"}},
+		{Version: 9, Name: "removed"},
+	}
+
+	records := mergeRecords(known, stored)
+
+	assert.Len(t, records, 3)
+	assert.Equal(t, "one", records[0].Name)
+	assert.Equal(t, &applied, records[0].AppliedAt)
+	assert.Equal(t, 5, records[1].Items)
+	assert.Equal(t, "topic#u", records[1].Cursor["PK"])
+	assert.Equal(t, MigrationRecord{Version: 3, Name: "three"}, records[2])
+}
+
+func TestCursor(t *testing.T) {
+	cursor, err := toCursor(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, cursor)
+	assert.Nil(t, fromCursor(nil))
+
+	key := map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "topic#u"},
+		"SK": &types.AttributeValueMemberS{Value: "Ideas"},
+	}
+	cursor, err = toCursor(key)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"PK": "topic#u", "SK": "Ideas"}, cursor)
+	assert.Equal(t, key, fromCursor(cursor))
+
+	_, err = toCursor(map[string]types.AttributeValue{"PK": &types.AttributeValueMemberN{Value: "1"}})
+	assert.Error(t, err)
+}
@@ -2,11 +2,12 @@ package notes
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -14,7 +15,6 @@ import (
 	"github.com/gofrs/uuid"
 )
 
-const TableName = "go-service-notes"
 
 type User struct {
 	ID string
@@ -32,13 +32,46 @@ type UserInsert struct {
 type Topic struct {
 	Title string
 	Notes []Note
+	Position string
+	Pinned bool
+	Favorite bool
+	Archived bool
+	ArchivedAt *time.Time `dynamodbav:",omitempty"`
+	// JournalDates records the days that already have a journal entry in
+	// this topic, so entries can be created idempotently.
+	JournalDates []string `dynamodbav:",stringset,omitempty"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+// HasNote reports whether the topic has a note with the title.
+func (t Topic) HasNote(title string) bool {
+	return FindNote(t.Notes, title) != nil
+}
+
+// FindNote returns the note with the title in notes, or nil.
+func FindNote(notes []Note, title string) *Note {
+	for i := range notes {
+		if notes[i].Title == title {
+			return &notes[i]
+		}
+	}
+	return nil
+}
+
 type Note struct {
+	ID string `dynamodbav:",omitempty"`
 	Title string
 	Content string
+	Position string
+	Pinned bool
+	Favorite bool
+	Links []Link `dynamodbav:",omitempty"`
+	Tasks []Task `dynamodbav:",omitempty"`
+	Tags []string `dynamodbav:",omitempty"`
+	DueAt *time.Time `dynamodbav:",omitempty"`
+	RemindAt *time.Time `dynamodbav:",omitempty"`
+	RemindedAt *time.Time `dynamodbav:",omitempty"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -46,6 +79,12 @@ type Note struct {
 const (
 	userPrefix = "user"
 	topicPrefix = "topic"
+	templatePrefix = "template"
+	journalPrefix = "journal"
+	reminderPrefix = "reminder"
+	calendarPrefix = "calendar"
+	migrationPrefix = "migration"
+	emailPrefix = "email"
 )
 
 const (
@@ -53,6 +92,10 @@ const (
 	sk = "SK"
 )
 
+// ErrTopicExists is returned when inserting a topic whose title is already
+// taken.
+var ErrTopicExists = &Error{ErrConflict, "topic already exists"}
+
 type KeyValue struct {
 	Key string
 	Value string
@@ -63,7 +106,7 @@ type DBKey struct {
 	Sort KeyValue
 }
 
-func userKey(userEmail string) DBKey {
+func userKey(userID string) DBKey {
 	return DBKey{
 		Hash: KeyValue{
 			Key: pk,
@@ -71,7 +114,7 @@ func userKey(userEmail string) DBKey {
 		},
 		Sort: KeyValue{
 			Key: sk,
-			Value: userEmail,
+			Value: userID,
 		},
 	}
 }
@@ -90,14 +133,18 @@ func topicKey(userID string, title string) DBKey {
 }
 
 
-func GetAllForUser(ctx context.Context, UserID string) ([]Topic, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+// GetAllForUser returns the user's topics that are not archived.
+func (s *Store) GetAllForUser(ctx context.Context, UserID string) ([]Topic, error) {
+	topics, err := s.getTopics(ctx, UserID)
 	if err != nil {
-		return nil, fmt.Errorf("load default config, %w", err)
+		return nil, err
 	}
 
-	svc := dynamodb.NewFromConfig(cfg)
+	return filterArchived(topics, false), nil
+}
 
+// getTopics returns every topic of the user, archived or not, in display order.
+func (s *Store) getTopics(ctx context.Context, UserID string) ([]Topic, error) {
 	keyCond := expression.Key(pk).Equal(expression.Value(topicKey(UserID, "").Hash.Value))
 	builder := expression.NewBuilder().WithKeyCondition(keyCond)
 	expr, err := builder.Build()
@@ -110,10 +157,10 @@ func GetAllForUser(ctx context.Context, UserID string) ([]Topic, error) {
 		ProjectionExpression:      expr.Projection(),
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
-		TableName: aws.String(TableName),
+		TableName: aws.String(s.table),
 	}
 
-	resp, err := svc.Query(ctx, &queryInput)
+	resp, err := s.client.Query(ctx, &queryInput)
 
 	if err != nil {
 		return nil, fmt.Errorf("query, %w", err)
@@ -126,64 +173,71 @@ func GetAllForUser(ctx context.Context, UserID string) ([]Topic, error) {
 		return nil, fmt.Errorf("unmarshal list of maps, %w", err)
 	}
 
+	sortTopics(topics)
+	pinnedTopicsFirst(topics)
+	for _, topic := range topics {
+		sortNotes(topic.Notes)
+		pinnedNotesFirst(topic.Notes)
+	}
+
 	return topics, nil
 }
 
-func GetUserByEmail(ctx context.Context, email string) (*User, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+// GetUserByEmail finds a user by email, ignoring case, through the user's
+// email claim. It returns nil if there is no such user.
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	userID, err := s.getEmailClaim(ctx, email)
 	if err != nil {
-		return nil, fmt.Errorf("load default config, %w", err)
+		return nil, err
+	}
+	if userID == "" {
+		return s.getLegacyUserByEmail(ctx, email)
 	}
 
-	svc := dynamodb.NewFromConfig(cfg)
-
-	keyCond := expression.Key(pk).Equal(expression.Value(userKey("").Hash.Value))
-	expression.Key(sk).Equal(expression.Value(email))
-	builder := expression.NewBuilder().WithKeyCondition(keyCond)
-	expr, err := builder.Build()
+	resp, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key:       getKey(userKey(userID)),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("expression builder: %w", err)
+		return nil, fmt.Errorf("dynamo get item, %w", err)
 	}
-
-	queryInput := dynamodb.QueryInput{
-		KeyConditionExpression:    expr.KeyCondition(),
-		ProjectionExpression:      expr.Projection(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		TableName: aws.String(TableName),
+	if resp.Item == nil {
+		return nil, nil
 	}
 
-	resp, err := svc.Query(ctx, &queryInput)
-
+	var user User
+	err = attributevalue.UnmarshalMap(resp.Item, &user)
 	if err != nil {
-		return nil, fmt.Errorf("query, %w", err)
+		return nil, fmt.Errorf("unmarshal map, %w", err)
 	}
 
-	var users = []User{}
+	return &user, nil
+}
 
-	err = attributevalue.UnmarshalListOfMaps(resp.Items, &users)
+// GetUserByID reads a user by ID. Users stored before migration 3 are still
+// keyed by their email and are found through the user index instead.
+func (s *Store) GetUserByID(ctx context.Context, userID string) (*User, error) {
+	resp, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key:       getKey(userKey(userID)),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("unmarshal list of maps, %w", err)
+		return nil, fmt.Errorf("dynamo get item, %w", err)
 	}
-	if len(users) == 0 {
-		return nil, nil
+	if resp.Item == nil {
+		return s.getLegacyUserByID(ctx, userID)
 	}
 
-	if len(users) > 1 {
-		return nil, fmt.Errorf("more than 1 user with email %q, an error has occured in dynamo setup", email)
-	}
-
-	return &users[0], nil
-}
-
-func GetUserTopicByTitle(ctx context.Context, userID, title string) (*Topic, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	var user User
+	err = attributevalue.UnmarshalMap(resp.Item, &user)
 	if err != nil {
-		return nil, fmt.Errorf("load default config, %w", err)
+		return nil, fmt.Errorf("unmarshal map, %w", err)
 	}
 
-	svc := dynamodb.NewFromConfig(cfg)
+	return &user, nil
+}
 
+func (s *Store) GetUserTopicByTitle(ctx context.Context, userID, title string) (*Topic, error) {
 	keyCond := expression.Key(pk).Equal(expression.Value(topicKey(userID, userID).Hash.Value))
 	keyCond = keyCond.And(expression.Key(sk).Equal(expression.Value(topicKey(userID, title).Sort.Value)))
 	builder := expression.NewBuilder().WithKeyCondition(keyCond)
@@ -197,10 +251,10 @@ func GetUserTopicByTitle(ctx context.Context, userID, title string) (*Topic, err
 		ProjectionExpression:      expr.Projection(),
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
-		TableName: aws.String(TableName),
+		TableName: aws.String(s.table),
 	}
 
-	resp, err := svc.Query(ctx, &queryInput)
+	resp, err := s.client.Query(ctx, &queryInput)
 
 	if err != nil {
 		return nil, fmt.Errorf("query, %w", err)
@@ -213,23 +267,24 @@ func GetUserTopicByTitle(ctx context.Context, userID, title string) (*Topic, err
 		return nil, fmt.Errorf("unmarshal list of maps, %w", err)
 	}
 	if len(topic) == 0 {
-		return nil, nil
+		return nil, fmt.Errorf("topic %q: %w", title, ErrTopicNotFound)
 	}
 
 	if len(topic) > 1 {
 		return nil, fmt.Errorf("more than 1 topic found with the same title: %q userID: %q, an error has occured in dynamo setup, topics: %q, %q", title, userID, topic[0].Title, topic[1].Title)
 	}
 
+	sortNotes(topic[0].Notes)
+	pinnedNotesFirst(topic[0].Notes)
+
 	return &topic[0], nil
 }
 
-func InsertUser(ctx context.Context, userInsert UserInsert) (*User, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("load default config, %w", err)
-	}
-
-	foundUser, err := GetUserByEmail(ctx, userInsert.Email)
+// InsertUser creates a user, or returns the existing user with the same
+// email. The user and the claim on their email are written in one
+// transaction, so concurrent inserts of one email create a single user.
+func (s *Store) InsertUser(ctx context.Context, userInsert UserInsert) (*User, error) {
+	foundUser, err := s.GetUserByEmail(ctx, userInsert.Email)
 	if err != nil {
 		return nil, fmt.Errorf("get user by email, %w", err)
 	}
@@ -244,208 +299,329 @@ func InsertUser(ctx context.Context, userInsert UserInsert) (*User, error) {
 		ID: userID,
 		Name: userInsert.Name,
 		Surname: userInsert.Surname,
-		Email: userInsert.Email,
+		Email: NormalizeEmail(userInsert.Email),
 	}
 
-	item, err := attributevalue.MarshalMap(user)
+	claim, err := s.claimEmail(user)
 	if err != nil {
-		return nil, fmt.Errorf("dynamo marshal map, %w", err)
+		return nil, err
 	}
-
-	key := userKey(userInsert.Email)
-
-	hashValue, err := attributevalue.Marshal(aws.String(key.Hash.Value))
-	if err != nil {
-		return nil,  fmt.Errorf("marshal hash %q: %w", key.Hash.Value, err)
-	}
-	sortValue, err := attributevalue.Marshal(aws.String(key.Sort.Value))
+	item, err := userItem(user)
 	if err != nil {
-		return nil,  fmt.Errorf("marshal sort %q: %w", key.Sort.Value, err)
+		return nil, err
 	}
 
-	item[key.Hash.Key] = hashValue
-	item[key.Sort.Key] = sortValue
-
-	item[key.Hash.Key] = hashValue
-
-	svc := dynamodb.NewFromConfig(cfg)
-
-	_, err = svc.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(TableName),
-		Item:      item,
+	_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			claim,
+			{Put: &types.Put{TableName: aws.String(s.table), Item: item}},
+		},
 	})
-
+	if isTransactionConditionFailed(err, 0) {
+		// Another insert claimed the email first.
+		return s.GetUserByEmail(ctx, user.Email)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("dynamo put item, %w", err)
+		return nil, fmt.Errorf("dynamo transact write items, %w", err)
 	}
 
 	return &user, nil
 }
 
-func InsertTopic(ctx context.Context, userID string, title string) error {
-	cfg, err := config.LoadDefaultConfig(ctx)
+// InsertTopic creates an empty topic after the user's other topics. It fails
+// with ErrTopicExists rather than replace a topic with the same title.
+func (s *Store) InsertTopic(ctx context.Context, userID string, title string) error {
+	last, err := s.lastTopicPosition(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("last topic position, %w", err)
+	}
+
+	position, err := PositionBetween(last, "")
 	if err != nil {
-		return fmt.Errorf("load default config, %w", err)
+		return fmt.Errorf("position between, %w", err)
 	}
 
 	topic := Topic{
 		Title:	title,
+		Position: position,
 		CreatedAt: time.Now().UTC(),
 	}
 
-	item, err := attributevalue.MarshalMap(topic)
+	cond := expression.AttributeNotExists(expression.Name(pk))
+	err = s.putTopicIf(ctx, userID, topic, &cond)
+	if isConditionalCheckFailed(err) {
+		return fmt.Errorf("topic %q: %w", title, ErrTopicExists)
+	}
 	if err != nil {
-		return fmt.Errorf("dynamo marshal map, %w", err)
+		return err
 	}
 
-	key := topicKey(userID, title)
+	if needsRebalance(position) {
+		err = s.rebalanceTopics(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("rebalance topics, %w", err)
+		}
+	}
 
-	hashValue, err := attributevalue.Marshal(aws.String(key.Hash.Value))
-	if err != nil {
-		return fmt.Errorf("marshal hash %q: %w", key.Hash.Value, err)
+	return nil
+}
+
+// EnsureTopic creates the topic unless the user already has one with the
+// title, which is kept as it is. It reports whether the topic was created.
+func (s *Store) EnsureTopic(ctx context.Context, userID string, title string) (bool, error) {
+	err := s.InsertTopic(ctx, userID, title)
+	if errors.Is(err, ErrTopicExists) {
+		return false, nil
 	}
-	sortValue, err := attributevalue.Marshal(aws.String(key.Sort.Value))
 	if err != nil {
-		return fmt.Errorf("marshal sort %q: %w", key.Sort.Value, err)
+		return false, err
 	}
+	return true, nil
+}
 
-	item[key.Hash.Key] = hashValue
-	item[key.Sort.Key] = sortValue
-
-	svc := dynamodb.NewFromConfig(cfg)
+func (s *Store) DeleteTopic(ctx context.Context, userID string, title string) error {
+	topic, err := s.GetUserTopicByTitle(ctx, userID, title)
+	if err != nil {
+		return fmt.Errorf("get user topic by title, %w", err)
+	}
 
-	_, err = svc.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(TableName),
-		Item:      item,
+	_, err = s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.table),
+		Key:       getTopicKey(userID, title),
 	})
 
 	if err != nil {
 		return fmt.Errorf("dynamo put item, %w", err)
 	}
 
-	return nil
+	return s.syncTopicReminders(ctx, userID, title, topic.Notes, nil)
 }
 
-func DeleteTopic(ctx context.Context, userID string, title string) error {
-	cfg, err := config.LoadDefaultConfig(ctx)
+func (s *Store) InsertNote(ctx context.Context, userID string, title string, note Note) error {
+	topic, err := s.GetUserTopicByTitle(ctx, userID, title)
 	if err != nil {
-		return fmt.Errorf("load default config, %w", err)
+		return fmt.Errorf("get user topic by title, %w", err)
+	}
+	if topic.Archived {
+		return fmt.Errorf("topic %q: %w", title, ErrTopicArchived)
+	}
+	if topic.HasNote(note.Title) {
+		return fmt.Errorf("note %q in topic %q: %w", note.Title, title, ErrNoteExists)
 	}
 
-	svc := dynamodb.NewFromConfig(cfg)
+	now := time.Now().UTC()
+	if note.CreatedAt.IsZero() {
+		note.CreatedAt = now
+	}
+	if note.UpdatedAt.IsZero() {
+		note.UpdatedAt = note.CreatedAt
+	}
 
-	_, err = svc.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		TableName: aws.String(TableName),
-		Key:       getTopicKey(userID, title),
-	})
+	note.Tags = NormalizeTags(note.Tags)
+	indexNote(&note)
 
+	note.Position, err = lastNotePosition(topic.Notes)
 	if err != nil {
-		return fmt.Errorf("dynamo put item, %w", err)
+		return fmt.Errorf("last note position, %w", err)
 	}
 
-	return nil
-}
+	topic.Notes = append(topic.Notes, note)
+	if needsRebalance(note.Position) {
+		rebalanceNotes(topic.Notes)
+	}
+	topic.UpdatedAt = now
 
-func InsertNote(ctx context.Context, userID string, title string, note Note) error {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	err = s.putTopic(ctx, userID, *topic)
 	if err != nil {
-		return fmt.Errorf("load default config, %w", err)
+		return err
 	}
 
-	topic, err := GetUserTopicByTitle(ctx, userID, title)
+	return s.syncReminder(ctx, userID, title, nil, title, &note)
+}
+
+func (s *Store) DeleteNote(ctx context.Context, userID string, title string, NoteTitle string) error {
+	topic, err := s.GetUserTopicByTitle(ctx, userID, title)
 	if err != nil {
 		return fmt.Errorf("get user topic by title, %w", err)
 	}
-	if topic == nil {
-		return fmt.Errorf("unknown topic %q, for userID %q", title, userID)
+
+	deleted := FindNote(topic.Notes, NoteTitle)
+	if deleted != nil {
+		copied := *deleted
+		deleted = &copied
 	}
 
-	topic.Notes = append(topic.Notes, note)
+	for s, note := range topic.Notes{
+		if note.Title == NoteTitle {
+			topic.Notes = append(topic.Notes[:s], topic.Notes[s+1:]...)	
+		}		
+	}
+
+	topic.forgetJournalDate(NoteTitle)
+	topic.UpdatedAt = time.Now().UTC()
 
-	item, err := attributevalue.MarshalMap(*topic)
+	err = s.putTopic(ctx, userID, *topic)
 	if err != nil {
-		return fmt.Errorf("dynamo marshal map, %w", err)
+		return err
 	}
 
-	key := topicKey(userID, title)
+	if deleted != nil {
+		return s.syncReminder(ctx, userID, title, deleted, title, nil)
+	}
 
-	hashValue, err := attributevalue.Marshal(aws.String(key.Hash.Value))
+	return nil
+}
+
+// indexNote refreshes the fields derived from a note's content, and gives
+// the note an ID if it has none yet. It runs on every write of a note.
+func indexNote(note *Note) {
+	if note.ID == "" {
+		note.ID = uuid.Must(uuid.NewV4()).String()
+	}
+
+	note.Links = ParseLinks(note.Content)
+	if len(note.Links) == 0 {
+		note.Links = nil
+	}
+
+	note.Tasks = ParseTasks(note.Content)
+	if len(note.Tasks) == 0 {
+		note.Tasks = nil
+	}
+}
+
+// NormalizeTags trims tags and a leading '#', dropping empty and duplicate
+// ones. Tags compare case-insensitively; the first spelling wins.
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// updateNote loads the topic, applies update to the named note and writes the
+// topic back. Derived fields are refreshed after update runs.
+func (s *Store) updateNote(ctx context.Context, userID, title, noteTitle string, update func(note *Note) error) error {
+	topic, err := s.GetUserTopicByTitle(ctx, userID, title)
 	if err != nil {
-		return fmt.Errorf("marshal hash %q: %w", key.Hash.Value, err)
+		return fmt.Errorf("get user topic by title, %w", err)
 	}
-	sortValue, err := attributevalue.Marshal(aws.String(key.Sort.Value))
+
+	note := FindNote(topic.Notes, noteTitle)
+	if note == nil {
+		return fmt.Errorf("note %q in topic %q: %w", noteTitle, title, ErrNoteNotFound)
+	}
+
+	err = update(note)
 	if err != nil {
-		return fmt.Errorf("marshal sort %q: %w", key.Sort.Value, err)
+		return err
 	}
+	indexNote(note)
+	topic.UpdatedAt = time.Now().UTC()
 
-	item[key.Hash.Key] = hashValue
-	item[key.Sort.Key] = sortValue
+	return s.putTopic(ctx, userID, *topic)
+}
 
-	svc := dynamodb.NewFromConfig(cfg)
+// updateTopic applies update to an existing topic item without touching its
+// embedded notes.
+func (s *Store) updateTopic(ctx context.Context, userID, title string, update expression.UpdateBuilder) error {
+	update = update.Set(expression.Name("UpdatedAt"), expression.Value(time.Now().UTC()))
+	cond := expression.AttributeExists(expression.Name(pk))
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(cond).Build()
+	if err != nil {
+		return fmt.Errorf("expression builder: %w", err)
+	}
 
-	_, err = svc.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(TableName),
-		Item:      item,
+	_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       getTopicKey(userID, title),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
 	})
-
+	if isConditionalCheckFailed(err) {
+		return fmt.Errorf("topic %q: %w", title, ErrTopicNotFound)
+	}
 	if err != nil {
-		return fmt.Errorf("dynamo put item, %w", err)
+		return fmt.Errorf("dynamo update item, %w", err)
 	}
 
 	return nil
 }
 
-func DeleteNote(ctx context.Context, userID string, title string, NoteTitle string) error {
-	cfg, err := config.LoadDefaultConfig(ctx)
+// putTopic writes the whole topic item, including its embedded notes.
+func (s *Store) putTopic(ctx context.Context, userID string, topic Topic) error {
+	return s.putTopicIf(ctx, userID, topic, nil)
+}
+
+// putTopicIf writes the topic if cond, when given, holds for the stored item.
+func (s *Store) putTopicIf(ctx context.Context, userID string, topic Topic, cond *expression.ConditionBuilder) error {
+	put, err := s.topicPut(userID, topic, cond)
 	if err != nil {
-		return fmt.Errorf("load default config, %w", err)
+		return err
 	}
 
-	topic, err := GetUserTopicByTitle(ctx, userID, title)
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 put.TableName,
+		Item:                      put.Item,
+		ConditionExpression:       put.ConditionExpression,
+		ExpressionAttributeNames:  put.ExpressionAttributeNames,
+		ExpressionAttributeValues: put.ExpressionAttributeValues,
+	})
+
 	if err != nil {
-		return fmt.Errorf("get user topic by title, %w", err)
-	}
-	if topic == nil {
-		return fmt.Errorf("unknown topic %q, for userID %q", title, userID)
+		return fmt.Errorf("dynamo put item, %w", err)
 	}
 
-	for s, note := range topic.Notes{
-		if note.Title == NoteTitle {
-			topic.Notes = append(topic.Notes[:s], topic.Notes[s+1:]...)	
-		}		
-	}
+	return nil
+}
 
-	item, err := attributevalue.MarshalMap(*topic)
+// topicPut builds the put of the whole topic item, conditional on cond when
+// given, for PutItem or a transaction.
+func (s *Store) topicPut(userID string, topic Topic, cond *expression.ConditionBuilder) (*types.Put, error) {
+	item, err := attributevalue.MarshalMap(topic)
 	if err != nil {
-		return fmt.Errorf("dynamo marshal map, %w", err)
+		return nil, fmt.Errorf("dynamo marshal map, %w", err)
 	}
 
-	key := topicKey(userID, title)
+	key := topicKey(userID, topic.Title)
 
 	hashValue, err := attributevalue.Marshal(aws.String(key.Hash.Value))
 	if err != nil {
-		return fmt.Errorf("marshal hash %q: %w", key.Hash.Value, err)
+		return nil, fmt.Errorf("marshal hash %q: %w", key.Hash.Value, err)
 	}
 	sortValue, err := attributevalue.Marshal(aws.String(key.Sort.Value))
 	if err != nil {
-		return fmt.Errorf("marshal sort %q: %w", key.Sort.Value, err)
+		return nil, fmt.Errorf("marshal sort %q: %w", key.Sort.Value, err)
 	}
 
 	item[key.Hash.Key] = hashValue
 	item[key.Sort.Key] = sortValue
 
-	svc := dynamodb.NewFromConfig(cfg)
-
-	_, err = svc.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(TableName),
+	put := &types.Put{
+		TableName: aws.String(s.table),
 		Item:      item,
-	})
-
-	if err != nil {
-		return fmt.Errorf("dynamo put item, %w", err)
+	}
+	if cond != nil {
+		expr, err := expression.NewBuilder().WithCondition(*cond).Build()
+		if err != nil {
+			return nil, fmt.Errorf("expression builder: %w", err)
+		}
+		put.ConditionExpression = expr.Condition()
+		put.ExpressionAttributeNames = expr.Names()
+		put.ExpressionAttributeValues = expr.Values()
 	}
 
-	return nil
+	return put, nil
 }
 
 func getTopicKey(userID string, title string) map[string]types.AttributeValue {
@@ -462,6 +638,20 @@ func getTopicKey(userID string, title string) map[string]types.AttributeValue {
 	return map[string]types.AttributeValue{pk: hash, sk: sort}
 }
 
+// getKey marshals any DBKey into a DynamoDB key.
+func getKey(key DBKey) map[string]types.AttributeValue {
+	hash, err := attributevalue.Marshal(key.Hash.Value)
+	if err != nil {
+		panic(err)
+	}
+	sort, err := attributevalue.Marshal(key.Sort.Value)
+	if err != nil {
+		panic(err)
+	}
+
+	return map[string]types.AttributeValue{key.Hash.Key: hash, key.Sort.Key: sort}
+}
+
 func getTopicNoteKey(userID string, title string) map[string]types.AttributeValue {
 	key := topicKey(userID, title)
 	hash, err := attributevalue.Marshal(key.Hash.Value)
@@ -0,0 +1,227 @@
+package notes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// MoveTopic places a topic between its new neighbours, identified by title.
+// An empty previous moves the topic to the start, an empty next to the end.
+// Only the moved topic is written unless its new key triggers a rebalance.
+func (s *Store) MoveTopic(ctx context.Context, userID, title, previous, next string) error {
+	topics, err := s.topicPositions(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("topic positions, %w", err)
+	}
+
+	for _, topic := range topics {
+		if topic.Position == "" {
+			err = s.spreadTopics(ctx, userID, topics)
+			if err != nil {
+				return fmt.Errorf("rebalance topics, %w", err)
+			}
+			break
+		}
+	}
+
+	positions := map[string]string{}
+	for _, topic := range topics {
+		positions[topic.Title] = topic.Position
+	}
+
+	if _, ok := positions[title]; !ok {
+		return fmt.Errorf("topic %q: %w", title, ErrTopicNotFound)
+	}
+
+	before, after, err := neighbourPositions(positions, title, previous, next)
+	if err != nil {
+		return err
+	}
+
+	position, err := PositionBetween(before, after)
+	if err != nil {
+		return fmt.Errorf("position between, %w", err)
+	}
+
+	err = s.setTopicPosition(ctx, userID, title, position)
+	if err != nil {
+		return err
+	}
+
+	if needsRebalance(position) {
+		err = s.rebalanceTopics(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("rebalance topics, %w", err)
+		}
+	}
+
+	return nil
+}
+
+// MoveNote places a note between its new neighbours within the same topic.
+// Notes are embedded in the topic item, so a move is a single write.
+func (s *Store) MoveNote(ctx context.Context, userID, title, noteTitle, previous, next string) error {
+	topic, err := s.GetUserTopicByTitle(ctx, userID, title)
+	if err != nil {
+		return fmt.Errorf("get user topic by title, %w", err)
+	}
+
+	positions := map[string]string{}
+	for _, note := range topic.Notes {
+		if note.Position == "" {
+			rebalanceNotes(topic.Notes)
+			break
+		}
+	}
+	for _, note := range topic.Notes {
+		positions[note.Title] = note.Position
+	}
+
+	index := -1
+	for i, note := range topic.Notes {
+		if note.Title == noteTitle {
+			index = i
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("note %q in topic %q: %w", noteTitle, title, ErrNoteNotFound)
+	}
+
+	before, after, err := neighbourPositions(positions, noteTitle, previous, next)
+	if err != nil {
+		return err
+	}
+
+	position, err := PositionBetween(before, after)
+	if err != nil {
+		return fmt.Errorf("position between, %w", err)
+	}
+
+	topic.Notes[index].Position = position
+	if needsRebalance(position) {
+		rebalanceNotes(topic.Notes)
+	}
+	topic.UpdatedAt = time.Now().UTC()
+
+	return s.putTopic(ctx, userID, *topic)
+}
+
+// neighbourPositions returns the positions of the neighbours titled previous
+// and next of the item titled moved. Neighbours that do not exist, the moved
+// item itself or neighbours out of order are validation errors.
+func neighbourPositions(positions map[string]string, moved, previous, next string) (string, string, error) {
+	if previous == moved || next == moved {
+		return "", "", invalidf("cannot move %q next to itself", moved)
+	}
+
+	before := ""
+	if previous != "" {
+		position, ok := positions[previous]
+		if !ok {
+			return "", "", invalidf("unknown previous neighbour %q", previous)
+		}
+		before = position
+	}
+
+	after := ""
+	if next != "" {
+		position, ok := positions[next]
+		if !ok {
+			return "", "", invalidf("unknown next neighbour %q", next)
+		}
+		after = position
+	}
+
+	if after != "" && before >= after {
+		return "", "", invalidf("previous neighbour %q is not before next neighbour %q", previous, next)
+	}
+
+	return before, after, nil
+}
+
+// topicPositions reads only the title, position and creation time of every
+// topic for the user, so reordering does not pull embedded notes.
+func (s *Store) topicPositions(ctx context.Context, userID string) ([]Topic, error) {
+	keyCond := expression.Key(pk).Equal(expression.Value(topicKey(userID, "").Hash.Value))
+	proj := expression.NamesList(expression.Name("Title"), expression.Name("Position"), expression.Name("CreatedAt"))
+	builder := expression.NewBuilder().WithKeyCondition(keyCond).WithProjection(proj)
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("expression builder: %w", err)
+	}
+
+	queryInput := dynamodb.QueryInput{
+		KeyConditionExpression:    expr.KeyCondition(),
+		ProjectionExpression:      expr.Projection(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		TableName:                 aws.String(s.table),
+	}
+
+	resp, err := s.client.Query(ctx, &queryInput)
+	if err != nil {
+		return nil, fmt.Errorf("query, %w", err)
+	}
+
+	var topics = []Topic{}
+
+	err = attributevalue.UnmarshalListOfMaps(resp.Items, &topics)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal list of maps, %w", err)
+	}
+
+	sortTopics(topics)
+
+	return topics, nil
+}
+
+func (s *Store) lastTopicPosition(ctx context.Context, userID string) (string, error) {
+	topics, err := s.topicPositions(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	last := ""
+	for _, topic := range topics {
+		if topic.Position > last {
+			last = topic.Position
+		}
+	}
+
+	return last, nil
+}
+
+// rebalanceTopics spreads every topic of the user onto short keys, keeping the
+// current display order.
+func (s *Store) rebalanceTopics(ctx context.Context, userID string) error {
+	topics, err := s.topicPositions(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("topic positions, %w", err)
+	}
+
+	return s.spreadTopics(ctx, userID, topics)
+}
+
+// spreadTopics writes evenly spaced positions for topics, which must already be
+// in display order, and updates the slice to match.
+func (s *Store) spreadTopics(ctx context.Context, userID string, topics []Topic) error {
+	for i, position := range SpreadPositions(len(topics)) {
+		err := s.setTopicPosition(ctx, userID, topics[i].Title, position)
+		if err != nil {
+			return err
+		}
+		topics[i].Position = position
+	}
+
+	return nil
+}
+
+func (s *Store) setTopicPosition(ctx context.Context, userID, title, position string) error {
+	return s.updateTopic(ctx, userID, title, expression.Set(expression.Name("Position"), expression.Value(position)))
+}
@@ -0,0 +1,191 @@
+package notes
+
+import (
+	"sort"
+	"strings"
+)
+
+// Positions are fractional index keys: strings over positionDigits that are
+// compared lexicographically and read as the fraction 0.<key> in base 62. A
+// new key can always be generated between two existing keys, so moving an item
+// only rewrites that item's Position.
+const positionDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// maxPositionLength is the key length after which siblings are rebalanced onto
+// short, evenly spaced keys.
+const maxPositionLength = 24
+
+// PositionBetween returns a key that sorts strictly after before and strictly
+// before after. An empty before means the start of the list and an empty after
+// means the end of the list.
+func PositionBetween(before, after string) (string, error) {
+	if err := validatePosition(before); err != nil {
+		return "", err
+	}
+	if err := validatePosition(after); err != nil {
+		return "", err
+	}
+	if after != "" && before >= after {
+		return "", invalidf("position %q is not before %q", before, after)
+	}
+
+	return midpoint(before, after), nil
+}
+
+// midpoint returns a key between a and b, where b == "" stands for 1.
+func midpoint(a, b string) string {
+	if b != "" {
+		n := 0
+		for n < len(b) && positionDigit(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			if n > len(a) {
+				return b[:n] + midpoint("", b[n:])
+			}
+			return b[:n] + midpoint(a[n:], b[n:])
+		}
+	}
+
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(positionDigits, a[0])
+	}
+	digitB := len(positionDigits)
+	if b != "" {
+		digitB = strings.IndexByte(positionDigits, b[0])
+	}
+
+	if digitB-digitA > 1 {
+		return string(positionDigits[(digitA+digitB+1)/2])
+	}
+
+	if len(b) > 1 {
+		return b[:1]
+	}
+
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(positionDigits[digitA]) + midpoint(rest, "")
+}
+
+func positionDigit(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return positionDigits[0]
+}
+
+func validatePosition(key string) error {
+	if key == "" {
+		return nil
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(positionDigits, key[i]) < 0 {
+			return invalidf("invalid position %q", key)
+		}
+	}
+	if key[len(key)-1] == positionDigits[0] {
+		return invalidf("invalid position %q, trailing zero", key)
+	}
+	return nil
+}
+
+// SpreadPositions returns n increasing keys spaced evenly across the key space,
+// using the shortest width that fits them.
+func SpreadPositions(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+
+	width := 1
+	space := len(positionDigits)
+	for space <= n {
+		width++
+		space *= len(positionDigits)
+	}
+
+	step := space / (n + 1)
+	keys := make([]string, n)
+	for i := range keys {
+		value := (i + 1) * step
+		digits := make([]byte, width)
+		for d := width - 1; d >= 0; d-- {
+			digits[d] = positionDigits[value%len(positionDigits)]
+			value /= len(positionDigits)
+		}
+		keys[i] = strings.TrimRight(string(digits), positionDigits[:1])
+	}
+
+	return keys
+}
+
+// needsRebalance reports whether a freshly generated key has grown long enough
+// that its siblings should be spread out again.
+func needsRebalance(key string) bool {
+	return len(key) > maxPositionLength
+}
+
+// sortTopics orders topics by Position. Topics without a position (created
+// before ordering existed) sort after positioned ones, oldest first.
+func sortTopics(topics []Topic) {
+	sort.SliceStable(topics, func(i, j int) bool {
+		return lessPosition(topics[i].Position, topics[j].Position, topics[i].CreatedAt.Before(topics[j].CreatedAt))
+	})
+}
+
+// sortNotes orders notes the same way sortTopics orders topics.
+func sortNotes(notes []Note) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		return lessPosition(notes[i].Position, notes[j].Position, notes[i].CreatedAt.Before(notes[j].CreatedAt))
+	})
+}
+
+// pinnedTopicsFirst moves pinned topics ahead of the rest for display, keeping
+// the manual order within each group.
+func pinnedTopicsFirst(topics []Topic) {
+	sort.SliceStable(topics, func(i, j int) bool {
+		return topics[i].Pinned && !topics[j].Pinned
+	})
+}
+
+// pinnedNotesFirst does the same for notes within a topic.
+func pinnedNotesFirst(notes []Note) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].Pinned && !notes[j].Pinned
+	})
+}
+
+func lessPosition(a, b string, olderFirst bool) bool {
+	switch {
+	case a == "" && b == "":
+		return olderFirst
+	case a == "":
+		return false
+	case b == "":
+		return true
+	}
+	return a < b
+}
+
+// lastNotePosition returns a position after every note in the slice.
+func lastNotePosition(notes []Note) (string, error) {
+	last := ""
+	for _, note := range notes {
+		if note.Position > last {
+			last = note.Position
+		}
+	}
+	return PositionBetween(last, "")
+}
+
+// rebalanceNotes assigns fresh evenly spaced positions to notes, keeping their
+// manual order.
+func rebalanceNotes(notes []Note) {
+	sortNotes(notes)
+	for i, key := range SpreadPositions(len(notes)) {
+		notes[i].Position = key
+	}
+}
@@ -0,0 +1,118 @@
+package notes
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionBetween(t *testing.T) {
+	cases := []struct {
+		before string
+		after  string
+	}{
+		{"", ""},
+		{"", "V"},
+		{"V", ""},
+		{"A", "B"},
+		{"A", "A1"},
+		{"A1", "A2"},
+		{"zz", ""},
+		{"", "01"},
+	}
+
+	for _, c := range cases {
+		key, err := PositionBetween(c.before, c.after)
+		assert.NoError(t, err)
+		assert.Greater(t, key, c.before)
+		if c.after != "" {
+			assert.Less(t, key, c.after)
+		}
+		assert.NoError(t, validatePosition(key))
+	}
+}
+
+func TestPositionBetween_Invalid(t *testing.T) {
+	_, err := PositionBetween("B", "A")
+	assert.Error(t, err)
+
+	_, err = PositionBetween("A0", "")
+	assert.Error(t, err)
+
+	_, err = PositionBetween("A-", "")
+	assert.Error(t, err)
+}
+
+func TestNeighbourPositions(t *testing.T) {
+	positions := map[string]string{"Work": "A", "Home": "B", "Ideas": "C"}
+
+	before, after, err := neighbourPositions(positions, "Ideas", "Work", "Home")
+	assert.NoError(t, err)
+	assert.Equal(t, "A", before)
+	assert.Equal(t, "B", after)
+
+	for _, c := range [][2]string{{"Home", "Work"}, {"Work", "Work"}, {"Ideas", ""}, {"", "Ideas"}, {"Later", ""}} {
+		_, _, err := neighbourPositions(positions, "Ideas", c[0], c[1])
+		assert.True(t, errors.Is(err, ErrValidation), "%q, %q: %v", c[0], c[1], err)
+	}
+}
+
+func TestPositionBetween_RepeatedInsertsGrowSlowly(t *testing.T) {
+	before, after := "", ""
+	for i := 0; i < 50; i++ {
+		key, err := PositionBetween(before, after)
+		assert.NoError(t, err)
+		after = key
+	}
+	assert.LessOrEqual(t, len(after), 10)
+}
+
+func TestSpreadPositions(t *testing.T) {
+	for _, n := range []int{1, 2, 61, 62, 500} {
+		keys := SpreadPositions(n)
+		assert.Len(t, keys, n)
+		for i := range keys {
+			assert.NoError(t, validatePosition(keys[i]))
+			assert.NotEmpty(t, keys[i])
+			if i > 0 {
+				assert.Less(t, keys[i-1], keys[i])
+			}
+		}
+	}
+}
+
+func TestRebalanceNotes_KeepsOrder(t *testing.T) {
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	notes := []Note{
+		{Title: "legacy", CreatedAt: created},
+		{Title: "second", Position: "V1"},
+		{Title: "first", Position: "V"},
+	}
+
+	rebalanceNotes(notes)
+
+	assert.Equal(t, "first", notes[0].Title)
+	assert.Equal(t, "second", notes[1].Title)
+	assert.Equal(t, "legacy", notes[2].Title)
+	assert.Less(t, notes[0].Position, notes[1].Position)
+	assert.Less(t, notes[1].Position, notes[2].Position)
+}
+
+func TestPinnedNotesFirst(t *testing.T) {
+	notes := []Note{
+		{Title: "a", Position: "A"},
+		{Title: "b", Position: "B", Pinned: true},
+		{Title: "c", Position: "C"},
+		{Title: "d", Position: "D", Pinned: true},
+	}
+
+	pinnedNotesFirst(notes)
+
+	titles := []string{}
+	for _, note := range notes {
+		titles = append(titles, note.Title)
+	}
+	assert.Equal(t, []string{"b", "d", "a", "c"}, titles)
+}
@@ -0,0 +1,277 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// reminderTimeLayout is a fixed width UTC layout, so reminder sort keys order
+// chronologically.
+const reminderTimeLayout = "2006-01-02T15:04:05Z"
+
+// Reminder is an index item pointing at a note whose reminder has not fired
+// yet. All reminders share one partition, sorted by RemindAt, so the
+// scheduled job can find the due ones with a single query.
+type Reminder struct {
+	UserID     string
+	TopicTitle string
+	NoteTitle  string
+	RemindAt   time.Time
+	DueAt      *time.Time `dynamodbav:",omitempty"`
+}
+
+// DueNote is a note with a due date, together with its topic.
+type DueNote struct {
+	TopicTitle string
+	Note       Note
+}
+
+// DueNotes splits the notes with a due date into those already overdue and
+// those due within the requested window.
+type DueNotes struct {
+	Overdue  []DueNote
+	Upcoming []DueNote
+}
+
+func reminderKey(reminder Reminder) DBKey {
+	return DBKey{
+		Hash: KeyValue{
+			Key:   pk,
+			Value: reminderPrefix,
+		},
+		Sort: KeyValue{
+			Key: sk,
+			Value: fmt.Sprintf("%s#%s#%s#%s", reminder.RemindAt.UTC().Format(reminderTimeLayout),
+				reminder.UserID, reminder.TopicTitle, reminder.NoteTitle),
+		},
+	}
+}
+
+// noteReminder returns the pending reminder for a note, if it has one.
+func noteReminder(userID, title string, note Note) (Reminder, bool) {
+	if note.RemindAt == nil || note.RemindedAt != nil {
+		return Reminder{}, false
+	}
+	return Reminder{
+		UserID:     userID,
+		TopicTitle: title,
+		NoteTitle:  note.Title,
+		RemindAt:   note.RemindAt.UTC().Truncate(time.Second),
+		DueAt:      note.DueAt,
+	}, true
+}
+
+// SetNoteDue sets or clears a note's due date and reminder time. Changing the
+// reminder time re-arms a reminder that has already fired.
+func (s *Store) SetNoteDue(ctx context.Context, userID, title, noteTitle string, dueAt, remindAt *time.Time) error {
+	var before, after Note
+	err := s.updateNote(ctx, userID, title, noteTitle, func(note *Note) error {
+		before = *note
+		if !sameTime(note.RemindAt, remindAt) {
+			note.RemindedAt = nil
+		}
+		note.DueAt = utcTime(dueAt)
+		note.RemindAt = utcTime(remindAt)
+		note.UpdatedAt = time.Now().UTC()
+		after = *note
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.syncReminder(ctx, userID, title, &before, title, &after)
+}
+
+// GetDueNotes returns the user's overdue notes and the notes due within the
+// given window from now, each sorted by due date. Archived topics are left
+// out.
+func (s *Store) GetDueNotes(ctx context.Context, userID string, now time.Time, within time.Duration) (*DueNotes, error) {
+	topics, err := s.GetAllForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get all for user, %w", err)
+	}
+
+	return dueNotes(topics, now, within), nil
+}
+
+func dueNotes(topics []Topic, now time.Time, within time.Duration) *DueNotes {
+	due := DueNotes{
+		Overdue:  []DueNote{},
+		Upcoming: []DueNote{},
+	}
+
+	for _, topic := range topics {
+		for _, note := range topic.Notes {
+			if note.DueAt == nil {
+				continue
+			}
+			switch {
+			case note.DueAt.Before(now):
+				due.Overdue = append(due.Overdue, DueNote{TopicTitle: topic.Title, Note: note})
+			case !note.DueAt.After(now.Add(within)):
+				due.Upcoming = append(due.Upcoming, DueNote{TopicTitle: topic.Title, Note: note})
+			}
+		}
+	}
+
+	for _, list := range [][]DueNote{due.Overdue, due.Upcoming} {
+		list := list
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].Note.DueAt.Before(*list[j].Note.DueAt)
+		})
+	}
+
+	return &due
+}
+
+// DueReminders returns a page of at most limit pending reminders whose time
+// is at or before the given time, oldest first, and the cursor of the next
+// page. The cursor is empty after the last page; a page may be short, or
+// empty, before that. Pass the cursor as after to read the next page.
+func (s *Store) DueReminders(ctx context.Context, before time.Time, after string, limit int32) ([]Reminder, string, error) {
+	upper := before.UTC().Truncate(time.Second).Add(time.Second).Format(reminderTimeLayout)
+	keyCond := expression.Key(pk).Equal(expression.Value(reminderPrefix)).
+		And(expression.Key(sk).LessThan(expression.Value(upper)))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, "", fmt.Errorf("expression builder: %w", err)
+	}
+
+	input := &dynamodb.QueryInput{
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		TableName:                 aws.String(s.table),
+		Limit:                     aws.Int32(limit),
+	}
+	if after != "" {
+		input.ExclusiveStartKey = getKey(DBKey{
+			Hash: KeyValue{Key: pk, Value: reminderPrefix},
+			Sort: KeyValue{Key: sk, Value: after},
+		})
+	}
+
+	resp, err := s.client.Query(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("query, %w", err)
+	}
+
+	var reminders = []Reminder{}
+
+	err = attributevalue.UnmarshalListOfMaps(resp.Items, &reminders)
+	if err != nil {
+		return nil, "", fmt.Errorf("unmarshal list of maps, %w", err)
+	}
+
+	next := ""
+	if resp.LastEvaluatedKey != nil {
+		err = attributevalue.Unmarshal(resp.LastEvaluatedKey[sk], &next)
+		if err != nil {
+			return nil, "", fmt.Errorf("unmarshal last evaluated key, %w", err)
+		}
+	}
+
+	return reminders, next, nil
+}
+
+// MarkReminded records that a reminder has been sent and removes it from the
+// pending index. A reminder for a note that no longer exists is just removed.
+func (s *Store) MarkReminded(ctx context.Context, reminder Reminder, at time.Time) error {
+	err := s.updateNote(ctx, reminder.UserID, reminder.TopicTitle, reminder.NoteTitle, func(note *Note) error {
+		remindedAt := at.UTC()
+		note.RemindedAt = &remindedAt
+		return nil
+	})
+	if err != nil {
+		// The note may have gone with its topic, which leaves only the
+		// reminder to delete.
+		topic, getErr := s.GetUserTopicByTitle(ctx, reminder.UserID, reminder.TopicTitle)
+		if !errors.Is(getErr, ErrTopicNotFound) && (getErr != nil || FindNote(topic.Notes, reminder.NoteTitle) != nil) {
+			return fmt.Errorf("mark reminded, %w", err)
+		}
+	}
+
+	return s.deleteReminder(ctx, reminder)
+}
+
+// syncReminder brings the reminder index in line with a note that changed
+// from before to after. Either may be nil when the note was created or
+// deleted.
+func (s *Store) syncReminder(ctx context.Context, userID, beforeTopic string, before *Note, afterTopic string, after *Note) error {
+	var old, next Reminder
+	var hadOld, hasNext bool
+	if before != nil {
+		old, hadOld = noteReminder(userID, beforeTopic, *before)
+	}
+	if after != nil {
+		next, hasNext = noteReminder(userID, afterTopic, *after)
+	}
+
+	if hadOld && (!hasNext || reminderKey(old) != reminderKey(next)) {
+		err := s.deleteReminder(ctx, old)
+		if err != nil {
+			return err
+		}
+	}
+	if hasNext {
+		return s.putReminder(ctx, next)
+	}
+
+	return nil
+}
+
+func (s *Store) putReminder(ctx context.Context, reminder Reminder) error {
+	item, err := attributevalue.MarshalMap(reminder)
+	if err != nil {
+		return fmt.Errorf("dynamo marshal map, %w", err)
+	}
+	for name, value := range getKey(reminderKey(reminder)) {
+		item[name] = value
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo put item, %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) deleteReminder(ctx context.Context, reminder Reminder) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.table),
+		Key:       getKey(reminderKey(reminder)),
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo delete item, %w", err)
+	}
+
+	return nil
+}
+
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
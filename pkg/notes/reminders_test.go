@@ -0,0 +1,49 @@
+package notes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDueNotes(t *testing.T) {
+	now := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	topics := []Topic{
+		{Title: "Work", Notes: []Note{
+			{Title: "yesterday", DueAt: at(-24 * time.Hour)},
+			{Title: "tomorrow", DueAt: at(24 * time.Hour)},
+			{Title: "no due date"},
+			{Title: "next month", DueAt: at(30 * 24 * time.Hour)},
+		}},
+		{Title: "Home", Notes: []Note{
+			{Title: "an hour ago", DueAt: at(-time.Hour)},
+			{Title: "in an hour", DueAt: at(time.Hour)},
+		}},
+	}
+
+	due := dueNotes(topics, now, 7*24*time.Hour)
+
+	titles := func(list []DueNote) []string {
+		out := []string{}
+		for _, d := range list {
+			out = append(out, d.Note.Title)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"yesterday", "an hour ago"}, titles(due.Overdue))
+	assert.Equal(t, []string{"in an hour", "tomorrow"}, titles(due.Upcoming))
+}
+
+func TestReminderKey_SortsByTime(t *testing.T) {
+	early := reminderKey(Reminder{UserID: "b", RemindAt: time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)})
+	late := reminderKey(Reminder{UserID: "a", RemindAt: time.Date(2023, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))})
+
+	assert.Less(t, early.Sort.Value, late.Sort.Value)
+	assert.Equal(t, reminderPrefix, early.Hash.Value)
+}
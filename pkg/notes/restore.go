@@ -0,0 +1,73 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RestoreTopic writes a complete topic as given, replacing any topic with the
+// same title. Positions and timestamps are kept; invalid positions are
+// reassigned and derived note fields recomputed. The reminder index is
+// brought in line with the notes that were replaced.
+func (s *Store) RestoreTopic(ctx context.Context, userID string, topic Topic) error {
+	existing, err := s.GetUserTopicByTitle(ctx, userID, topic.Title)
+	if err != nil && !errors.Is(err, ErrTopicNotFound) {
+		return fmt.Errorf("get user topic by title, %w", err)
+	}
+
+	if validatePosition(topic.Position) != nil || topic.Position == "" {
+		last, err := s.lastTopicPosition(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("last topic position, %w", err)
+		}
+		if topic.Position, err = PositionBetween(last, ""); err != nil {
+			return fmt.Errorf("position between, %w", err)
+		}
+	}
+
+	rebalance := false
+	for i := range topic.Notes {
+		note := &topic.Notes[i]
+		note.Tags = NormalizeTags(note.Tags)
+		indexNote(note)
+		if note.Position == "" || validatePosition(note.Position) != nil {
+			rebalance = true
+		}
+	}
+	if rebalance {
+		rebalanceNotes(topic.Notes)
+	}
+
+	err = s.putTopic(ctx, userID, topic)
+	if err != nil {
+		return err
+	}
+
+	var before []Note
+	if existing != nil {
+		before = existing.Notes
+	}
+	return s.syncTopicReminders(ctx, userID, topic.Title, before, topic.Notes)
+}
+
+// syncTopicReminders updates the reminder index after the notes of a topic
+// were replaced wholesale. after is nil when the topic was deleted.
+func (s *Store) syncTopicReminders(ctx context.Context, userID, title string, before, after []Note) error {
+	for i := range before {
+		next := FindNote(after, before[i].Title)
+		if next == nil {
+			err := s.syncReminder(ctx, userID, title, &before[i], title, nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for i := range after {
+		err := s.syncReminder(ctx, userID, title, FindNote(before, after[i].Title), title, &after[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// GSI1 is a general purpose global secondary index over the GSI1PK and GSI1SK
+// attributes. Items opt in by setting both; users are indexed by ID.
+const (
+	gsi1   = "GSI1"
+	gsi1pk = "GSI1PK"
+	gsi1sk = "GSI1SK"
+)
+
+// tableWait bounds how long EnsureTable waits for the table or an index to
+// become active.
+const tableWait = 5 * time.Minute
+
+// indexPollInterval is how often EnsureTable checks on a new index.
+const indexPollInterval = 5 * time.Second
+
+// tableIndexes are the global secondary indexes the service needs.
+var tableIndexes = []types.GlobalSecondaryIndex{
+	{
+		IndexName: aws.String(gsi1),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(gsi1pk), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String(gsi1sk), KeyType: types.KeyTypeRange},
+		},
+		Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+	},
+}
+
+var tableAttributes = []types.AttributeDefinition{
+	{AttributeName: aws.String(pk), AttributeType: types.ScalarAttributeTypeS},
+	{AttributeName: aws.String(sk), AttributeType: types.ScalarAttributeTypeS},
+	{AttributeName: aws.String(gsi1pk), AttributeType: types.ScalarAttributeTypeS},
+	{AttributeName: aws.String(gsi1sk), AttributeType: types.ScalarAttributeTypeS},
+}
+
+func userIndexKey(userID string) DBKey {
+	return DBKey{
+		Hash: KeyValue{
+			Key:   gsi1pk,
+			Value: fmt.Sprintf("%s#%s", userPrefix, userID),
+		},
+		Sort: KeyValue{
+			Key:   gsi1sk,
+			Value: userPrefix,
+		},
+	}
+}
+
+// EnsureTable creates the table and its indexes, billed on demand, adding
+// any index an existing table lacks. It waits until everything is active and
+// returns what it changed, so running it again is harmless.
+func (s *Store) EnsureTable(ctx context.Context) ([]string, error) {
+	changes := []string{}
+
+	_, err := s.client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:            aws.String(s.table),
+		AttributeDefinitions: tableAttributes,
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(pk), KeyType: types.KeyTypeHash},
+			{AttributeName: aws.String(sk), KeyType: types.KeyTypeRange},
+		},
+		GlobalSecondaryIndexes: tableIndexes,
+		BillingMode:            types.BillingModePayPerRequest,
+	})
+	var inUse *types.ResourceInUseException
+	switch {
+	case errors.As(err, &inUse):
+	case err != nil:
+		return nil, fmt.Errorf("dynamo create table, %w", err)
+	default:
+		changes = append(changes, fmt.Sprintf("created table %s", s.table))
+	}
+
+	waiter := dynamodb.NewTableExistsWaiter(s.client)
+	err = waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)}, tableWait)
+	if err != nil {
+		return changes, fmt.Errorf("wait for table, %w", err)
+	}
+
+	for _, index := range tableIndexes {
+		created, err := s.ensureIndex(ctx, index)
+		if err != nil {
+			return changes, fmt.Errorf("index %s, %w", *index.IndexName, err)
+		}
+		if created {
+			changes = append(changes, fmt.Sprintf("created index %s", *index.IndexName))
+		}
+	}
+
+	return changes, nil
+}
+
+// ensureIndex adds a global secondary index to the table if it is missing and
+// waits for it to become active. DynamoDB builds one index at a time.
+func (s *Store) ensureIndex(ctx context.Context, index types.GlobalSecondaryIndex) (bool, error) {
+	desc, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)})
+	if err != nil {
+		return false, fmt.Errorf("dynamo describe table, %w", err)
+	}
+
+	created := false
+	if indexStatus(desc.Table, *index.IndexName) == "" {
+		create := &types.CreateGlobalSecondaryIndexAction{
+			IndexName:  index.IndexName,
+			KeySchema:  index.KeySchema,
+			Projection: index.Projection,
+		}
+		if desc.Table.BillingModeSummary == nil || desc.Table.BillingModeSummary.BillingMode == types.BillingModeProvisioned {
+			create.ProvisionedThroughput = &types.ProvisionedThroughput{
+				ReadCapacityUnits:  desc.Table.ProvisionedThroughput.ReadCapacityUnits,
+				WriteCapacityUnits: desc.Table.ProvisionedThroughput.WriteCapacityUnits,
+			}
+		}
+
+		_, err = s.client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName:                   aws.String(s.table),
+			AttributeDefinitions:        tableAttributes,
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{{Create: create}},
+		})
+		if err != nil {
+			return false, fmt.Errorf("dynamo update table, %w", err)
+		}
+		created = true
+	}
+
+	deadline := time.Now().Add(tableWait)
+	for {
+		desc, err = s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)})
+		if err != nil {
+			return created, fmt.Errorf("dynamo describe table, %w", err)
+		}
+		if indexStatus(desc.Table, *index.IndexName) == types.IndexStatusActive {
+			return created, nil
+		}
+		if time.Now().After(deadline) {
+			return created, fmt.Errorf("not active after %s", tableWait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return created, ctx.Err()
+		case <-time.After(indexPollInterval):
+		}
+	}
+}
+
+func indexStatus(table *types.TableDescription, name string) types.IndexStatus {
+	for _, index := range table.GlobalSecondaryIndexes {
+		if aws.ToString(index.IndexName) == name {
+			return index.IndexStatus
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/logging"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// DefaultTableName is the table used unless Options names another.
+const DefaultTableName = "go-service-notes"
+
+// Options selects the table a store works against. Empty fields keep the
+// defaults: DefaultTableName, and the region and endpoint of the default AWS
+// configuration.
+type Options struct {
+	TableName string
+	Region    string
+	// Endpoint overrides the DynamoDB endpoint, e.g. http://localhost:8000
+	// for DynamoDB Local.
+	Endpoint string
+}
+
+// Store reads and writes every item of the service in one DynamoDB table.
+// Build it once at startup and share it; it is safe for concurrent use.
+type Store struct {
+	client *dynamodb.Client
+	table  string
+}
+
+// NewStore returns a store for table, DefaultTableName when empty, using
+// client, which NewClient builds.
+func NewStore(client *dynamodb.Client, table string) *Store {
+	if table == "" {
+		table = DefaultTableName
+	}
+	return &Store{client: client, table: table}
+}
+
+// Table is the name of the store's table.
+func (s *Store) Table() string {
+	return s.table
+}
+
+// NewClient returns a DynamoDB client for the region and endpoint of opts,
+// which logs every call.
+func NewClient(ctx context.Context, opts Options) (*dynamodb.Client, error) {
+	cfg, err := awsConfig(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("load default config, %w", err)
+	}
+	return dynamodb.NewFromConfig(cfg), nil
+}
+
+// awsConfig loads the default AWS configuration with the region and endpoint
+// of opts applied.
+func awsConfig(ctx context.Context, opts Options) (aws.Config, error) {
+	var optFns []func(*config.LoadOptions) error
+	if opts.Region != "" {
+		optFns = append(optFns, config.WithRegion(opts.Region))
+	}
+	if opts.Endpoint != "" {
+		endpoint := opts.Endpoint
+		optFns = append(optFns, config.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(
+			func(service, region string, _ ...interface{}) (aws.Endpoint, error) {
+				return aws.Endpoint{URL: endpoint, SigningRegion: region}, nil
+			},
+		)))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return cfg, err
+	}
+
+	table := opts.TableName
+	if table == "" {
+		table = DefaultTableName
+	}
+	cfg.APIOptions = append(cfg.APIOptions, operationLog(table))
+	return cfg, nil
+}
+
+// operationLog logs every DynamoDB call with the logger of its context,
+// which carries the request ID and user when called from the API. Calls
+// are logged at debug level; failures at warn, except failed conditions,
+// which the package uses for control flow.
+func operationLog(table string) func(stack *middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("NotesOperationLog",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				start := time.Now()
+				out, metadata, err := next.HandleInitialize(ctx, in)
+
+				logger := logging.FromContext(ctx).With(
+					"operation", awsmiddleware.GetOperationName(ctx),
+					"table", table,
+					"latency", time.Since(start),
+				)
+				var conditionFailed *types.ConditionalCheckFailedException
+				var transactionCanceled *types.TransactionCanceledException
+				switch {
+				case err == nil:
+					logger.DebugContext(ctx, "dynamodb")
+				case errors.As(err, &conditionFailed), errors.As(err, &transactionCanceled):
+					logger.DebugContext(ctx, "dynamodb condition failed", "error", err)
+				default:
+					logger.WarnContext(ctx, "dynamodb failed", "error", err)
+				}
+				return out, metadata, err
+			}), middleware.After)
+	}
+}
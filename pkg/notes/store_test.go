@@ -0,0 +1,63 @@
+package notes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/logging"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAWSConfig(t *testing.T) {
+	cfg, err := awsConfig(context.Background(), Options{Region: "eu-central-1", Endpoint: "http://localhost:8000"})
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-central-1", cfg.Region)
+
+	endpoint, err := cfg.EndpointResolverWithOptions.ResolveEndpoint("DynamoDB", cfg.Region)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:8000", endpoint.URL)
+}
+
+func TestNewStore(t *testing.T) {
+	assert.Equal(t, "notes-test", NewStore(nil, "notes-test").Table())
+	assert.Equal(t, DefaultTableName, NewStore(nil, "").Table())
+}
+
+func TestOperationLog(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := logging.NewContext(context.Background(), logging.New(&buf, "debug").With("requestId", "r1"))
+
+	run := func(err error) map[string]any {
+		buf.Reset()
+		stack := middleware.NewStack("test", smithyhttp.NewStackRequest)
+		assert.NoError(t, operationLog("notes-test")(stack))
+		handler := middleware.DecorateHandler(middleware.HandlerFunc(func(ctx context.Context, input any) (any, middleware.Metadata, error) {
+			return nil, middleware.Metadata{}, err
+		}), stack)
+		_, _, got := handler.Handle(ctx, nil)
+		assert.Equal(t, err, got)
+
+		line := map[string]any{}
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+		return line
+	}
+
+	line := run(nil)
+	assert.Equal(t, "DEBUG", line["level"])
+	assert.Equal(t, "r1", line["requestId"])
+	assert.Equal(t, "notes-test", line["table"])
+
+	line = run(fmt.Errorf("put, %w", &types.ConditionalCheckFailedException{}))
+	assert.Equal(t, "DEBUG", line["level"])
+
+	line = run(errors.New("throttled"))
+	assert.Equal(t, "WARN", line["level"])
+	assert.Equal(t, "throttled", line["error"])
+}
@@ -0,0 +1,143 @@
+package notes
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Task is a GFM task list item, "- [ ] text" or "- [x] text", parsed from a
+// note's content. Line is the zero-based line number of the item.
+type Task struct {
+	Line int
+	Text string
+	Done bool
+}
+
+// TaskRef is a task together with the note it was found in.
+type TaskRef struct {
+	TopicTitle string
+	NoteTitle  string
+	Task       Task
+}
+
+// Task status filters for GetTasks.
+const (
+	TaskStatusAll  = ""
+	TaskStatusOpen = "open"
+	TaskStatusDone = "done"
+)
+
+var (
+	// ErrTaskNotFound is returned when the given line is not a task item.
+	ErrTaskNotFound = &Error{ErrNotFound, "task not found"}
+	// ErrTaskChanged is returned when the task text no longer matches what
+	// the caller expected, usually because the note was edited meanwhile.
+	ErrTaskChanged = &Error{ErrConflict, "task changed"}
+)
+
+var taskPattern = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+\[)([ xX])(\]\s+)(.*)$`)
+
+// ParseTasks returns the task list items in markdown content, ignoring fenced
+// code blocks.
+func ParseTasks(content string) []Task {
+	tasks := []Task{}
+	fenced := false
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+
+		match := taskPattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+		tasks = append(tasks, Task{
+			Line: i,
+			Text: strings.TrimSpace(match[4]),
+			Done: match[2] != " ",
+		})
+	}
+	return tasks
+}
+
+// setTaskDone rewrites the checkbox on the given line of content. When text is
+// not empty it must match the task's current text.
+func setTaskDone(content string, line int, text string, done bool) (string, error) {
+	var task *Task
+	for _, t := range ParseTasks(content) {
+		if t.Line == line {
+			task = &t
+			break
+		}
+	}
+	if task == nil {
+		return "", fmt.Errorf("line %d: %w", line, ErrTaskNotFound)
+	}
+	if text != "" && strings.TrimSpace(text) != task.Text {
+		return "", fmt.Errorf("line %d is %q: %w", line, task.Text, ErrTaskChanged)
+	}
+
+	mark := " "
+	if done {
+		mark = "x"
+	}
+
+	lines := strings.Split(content, "\n")
+	lines[line] = taskPattern.ReplaceAllString(lines[line], "${1}"+mark+"${3}${4}")
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// GetTasks aggregates the task items of every note in the user's topics,
+// filtered by status. Archived topics are left out.
+func (s *Store) GetTasks(ctx context.Context, userID, status string) ([]TaskRef, error) {
+	switch status {
+	case TaskStatusAll, TaskStatusOpen, TaskStatusDone:
+	default:
+		return nil, invalidf("unknown task status %q", status)
+	}
+
+	topics, err := s.GetAllForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get all for user, %w", err)
+	}
+
+	tasks := []TaskRef{}
+	for _, topic := range topics {
+		for _, note := range topic.Notes {
+			for _, task := range note.Tasks {
+				if status == TaskStatusOpen && task.Done || status == TaskStatusDone && !task.Done {
+					continue
+				}
+				tasks = append(tasks, TaskRef{TopicTitle: topic.Title, NoteTitle: note.Title, Task: task})
+			}
+		}
+	}
+
+	return tasks, nil
+}
+
+// SetTaskDone checks or unchecks the task on the given line of a note by
+// rewriting the markdown. text, if set, guards against editing a line that
+// has changed since the caller last read it.
+func (s *Store) SetTaskDone(ctx context.Context, userID, title, noteTitle string, line int, text string, done bool) error {
+	return s.updateNote(ctx, userID, title, noteTitle, func(note *Note) error {
+		content, err := setTaskDone(note.Content, line, text, done)
+		if err != nil {
+			return err
+		}
+		if content != note.Content {
+			note.Content = content
+			note.UpdatedAt = time.Now().UTC()
+		}
+		return nil
+	})
+}
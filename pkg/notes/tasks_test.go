@@ -0,0 +1,46 @@
+package notes
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const taskContent = "# Sprint\n" +
+	"- [ ] write docs\n" +
+	"  * [x] review PR\n" +
+	"1. [X] deploy\n" +
+	"- [] not a task\n" +
+	"```\n- [ ] in code\n```\n" +
+	"+ [ ]   ship it  "
+
+func TestParseTasks(t *testing.T) {
+	tasks := ParseTasks(taskContent)
+
+	assert.Equal(t, []Task{
+		{Line: 1, Text: "write docs", Done: false},
+		{Line: 2, Text: "review PR", Done: true},
+		{Line: 3, Text: "deploy", Done: true},
+		{Line: 8, Text: "ship it", Done: false},
+	}, tasks)
+}
+
+func TestSetTaskDone(t *testing.T) {
+	content, err := setTaskDone(taskContent, 1, "write docs", true)
+	assert.NoError(t, err)
+	assert.Contains(t, content, "- [x] write docs\n")
+
+	content, err = setTaskDone(content, 2, "", false)
+	assert.NoError(t, err)
+	assert.Contains(t, content, "  * [ ] review PR\n")
+
+	_, err = setTaskDone(taskContent, 0, "", true)
+	assert.True(t, errors.Is(err, ErrTaskNotFound))
+
+	_, err = setTaskDone(taskContent, 6, "", true)
+	assert.True(t, errors.Is(err, ErrTaskNotFound))
+
+	_, err = setTaskDone(taskContent, 1, "write tests", true)
+	assert.True(t, errors.Is(err, ErrTaskChanged))
+}
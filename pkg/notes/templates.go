@@ -0,0 +1,294 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Template is a user-owned note blueprint. Title and Content may contain
+// placeholders written as {{name}}. The built-in placeholders are date, time,
+// topic and user; anything else is a custom field supplied when the note is
+// created.
+type Template struct {
+	Name      string
+	Title     string
+	Content   string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TemplateValues are the values substituted into a template.
+type TemplateValues struct {
+	Now        time.Time
+	TopicTitle string
+	UserName   string
+	Fields     map[string]string
+}
+
+var (
+	// ErrTemplateExists is returned when inserting a template whose name is
+	// already taken.
+	ErrTemplateExists = &Error{ErrConflict, "template already exists"}
+	// ErrTemplateNotFound is returned when a template does not exist.
+	ErrTemplateNotFound = &Error{ErrNotFound, "template not found"}
+	// ErrMissingTemplateFields is returned when a template uses custom fields
+	// that were not supplied.
+	ErrMissingTemplateFields = &Error{ErrValidation, "missing template fields"}
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+func templateKey(userID string, name string) DBKey {
+	return DBKey{
+		Hash: KeyValue{
+			Key:   pk,
+			Value: fmt.Sprintf("%s#%s", templatePrefix, userID),
+		},
+		Sort: KeyValue{
+			Key:   sk,
+			Value: name,
+		},
+	}
+}
+
+// Placeholders returns the distinct custom field names used by the template,
+// sorted by name.
+func (t Template) Placeholders() []string {
+	seen := map[string]bool{}
+	fields := []string{}
+	for _, text := range []string{t.Title, t.Content} {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+			name := match[1]
+			if builtinPlaceholder(name) || seen[name] {
+				continue
+			}
+			seen[name] = true
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Render fills in the template's placeholders and returns the resulting note.
+// All custom fields must be supplied.
+func (t Template) Render(values TemplateValues) (Note, error) {
+	missing := []string{}
+	for _, field := range t.Placeholders() {
+		if _, ok := values.Fields[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return Note{}, fmt.Errorf("%w: %s", ErrMissingTemplateFields, strings.Join(missing, ", "))
+	}
+
+	replace := func(text string) string {
+		return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+			name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+			switch name {
+			case "date":
+				return values.Now.Format("2006-01-02")
+			case "time":
+				return values.Now.Format("15:04")
+			case "topic":
+				return values.TopicTitle
+			case "user":
+				return values.UserName
+			}
+			return values.Fields[name]
+		})
+	}
+
+	return Note{
+		Title:   strings.TrimSpace(replace(t.Title)),
+		Content: replace(t.Content),
+	}, nil
+}
+
+func builtinPlaceholder(name string) bool {
+	switch name {
+	case "date", "time", "topic", "user":
+		return true
+	}
+	return false
+}
+
+// GetTemplates lists the user's templates by name.
+func (s *Store) GetTemplates(ctx context.Context, userID string) ([]Template, error) {
+	keyCond := expression.Key(pk).Equal(expression.Value(templateKey(userID, "").Hash.Value))
+	builder := expression.NewBuilder().WithKeyCondition(keyCond)
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("expression builder: %w", err)
+	}
+
+	resp, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		TableName:                 aws.String(s.table),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query, %w", err)
+	}
+
+	var templates = []Template{}
+
+	err = attributevalue.UnmarshalListOfMaps(resp.Items, &templates)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal list of maps, %w", err)
+	}
+
+	return templates, nil
+}
+
+// GetTemplate returns the named template, or ErrTemplateNotFound.
+func (s *Store) GetTemplate(ctx context.Context, userID, name string) (*Template, error) {
+	resp, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key:       getKey(templateKey(userID, name)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dynamo get item, %w", err)
+	}
+	if resp.Item == nil {
+		return nil, fmt.Errorf("template %q: %w", name, ErrTemplateNotFound)
+	}
+
+	var template = Template{}
+
+	err = attributevalue.UnmarshalMap(resp.Item, &template)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal map, %w", err)
+	}
+
+	return &template, nil
+}
+
+// InsertTemplate creates a template. Names are unique per user.
+func (s *Store) InsertTemplate(ctx context.Context, userID string, template Template) error {
+	now := time.Now().UTC()
+	template.CreatedAt = now
+	template.UpdatedAt = now
+
+	err := s.putTemplate(ctx, userID, template, expression.AttributeNotExists(expression.Name(pk)))
+	if isConditionalCheckFailed(err) {
+		return fmt.Errorf("template %q: %w", template.Name, ErrTemplateExists)
+	}
+	return err
+}
+
+// UpdateTemplate replaces the title and content of an existing template.
+func (s *Store) UpdateTemplate(ctx context.Context, userID string, template Template) error {
+	existing, err := s.GetTemplate(ctx, userID, template.Name)
+	if err != nil {
+		return err
+	}
+
+	template.CreatedAt = existing.CreatedAt
+	template.UpdatedAt = time.Now().UTC()
+
+	err = s.putTemplate(ctx, userID, template, expression.AttributeExists(expression.Name(pk)))
+	if isConditionalCheckFailed(err) {
+		return fmt.Errorf("template %q: %w", template.Name, ErrTemplateNotFound)
+	}
+	return err
+}
+
+// DeleteTemplate removes a template. Notes created from it are unaffected.
+func (s *Store) DeleteTemplate(ctx context.Context, userID, name string) error {
+	expr, err := expression.NewBuilder().WithCondition(expression.AttributeExists(expression.Name(pk))).Build()
+	if err != nil {
+		return fmt.Errorf("expression builder: %w", err)
+	}
+
+	_, err = s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                aws.String(s.table),
+		Key:                      getKey(templateKey(userID, name)),
+		ConditionExpression:      expr.Condition(),
+		ExpressionAttributeNames: expr.Names(),
+	})
+	if isConditionalCheckFailed(err) {
+		return fmt.Errorf("template %q: %w", name, ErrTemplateNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("dynamo delete item, %w", err)
+	}
+
+	return nil
+}
+
+// NoteFromTemplate renders the named template for a new note in the given
+// topic, filling the user placeholder from the user's profile.
+func (s *Store) NoteFromTemplate(ctx context.Context, userID, topicTitle, name string, fields map[string]string) (*Note, error) {
+	template, err := s.GetTemplate(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user by id, %w", err)
+	}
+
+	values := TemplateValues{
+		Now:        time.Now().UTC(),
+		TopicTitle: topicTitle,
+		Fields:     fields,
+	}
+	if user != nil {
+		values.UserName = strings.TrimSpace(user.Name + " " + user.Surname)
+	}
+
+	note, err := template.Render(values)
+	if err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+func (s *Store) putTemplate(ctx context.Context, userID string, template Template, cond expression.ConditionBuilder) error {
+	item, err := attributevalue.MarshalMap(template)
+	if err != nil {
+		return fmt.Errorf("dynamo marshal map, %w", err)
+	}
+	for name, value := range getKey(templateKey(userID, template.Name)) {
+		item[name] = value
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		return fmt.Errorf("expression builder: %w", err)
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(s.table),
+		Item:                      item,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		return fmt.Errorf("dynamo put item, %w", err)
+	}
+
+	return nil
+}
+
+func isConditionalCheckFailed(err error) bool {
+	var conditionFailed *types.ConditionalCheckFailedException
+	return errors.As(err, &conditionFailed)
+}
@@ -0,0 +1,38 @@
+package notes
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateRender(t *testing.T) {
+	template := Template{
+		Name:    "meeting",
+		Title:   "{{date}} {{ subject }}",
+		Content: "# {{topic}}\nOwner: {{user}}\nStarted: {{time}}\nAttendees: {{attendees}}\n",
+	}
+
+	note, err := template.Render(TemplateValues{
+		Now:        time.Date(2023, 4, 5, 9, 30, 0, 0, time.UTC),
+		TopicTitle: "Projects",
+		UserName:   "Name_Test Surname_Test",
+		Fields:     map[string]string{"subject": "Planning", "attendees": "Ann, Bo"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-04-05 Planning", note.Title)
+	assert.Equal(t, "# Projects\nOwner: Name_Test Surname_Test\nStarted: 09:30\nAttendees: Ann, Bo\n", note.Content)
+}
+
+func TestTemplateRender_MissingFields(t *testing.T) {
+	template := Template{Title: "{{incident}}", Content: "{{severity}} {{date}}"}
+
+	assert.Equal(t, []string{"incident", "severity"}, template.Placeholders())
+
+	_, err := template.Render(TemplateValues{Fields: map[string]string{"incident": "outage"}})
+	assert.True(t, errors.Is(err, ErrMissingTemplateFields))
+	assert.Contains(t, err.Error(), "severity")
+}
@@ -0,0 +1,295 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Users are stored at PK "user", SK <id>. Each user also owns an email claim
+// at PK "email", SK <normalized email>, which maps the email to the ID. The
+// claim is written in the same transaction as the user and only if it does
+// not exist yet, so two users can never share an email.
+//
+// Before migration 3 users were stored at PK "user", SK <email as entered>
+// and had no claim; the lookups below fall back to that layout.
+
+// emailClaim is the item that reserves an email for a user.
+type emailClaim struct {
+	UserID string
+}
+
+// NormalizeEmail returns the form of an email used for lookups and
+// uniqueness: trimmed and lower case.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func emailKey(email string) DBKey {
+	return DBKey{
+		Hash: KeyValue{
+			Key:   pk,
+			Value: emailPrefix,
+		},
+		Sort: KeyValue{
+			Key:   sk,
+			Value: NormalizeEmail(email),
+		},
+	}
+}
+
+// legacyUserKey is where users were stored before migration 3.
+func legacyUserKey(email string) DBKey {
+	return DBKey{
+		Hash: KeyValue{
+			Key:   pk,
+			Value: userPrefix,
+		},
+		Sort: KeyValue{
+			Key:   sk,
+			Value: email,
+		},
+	}
+}
+
+// userItem marshals a user with its table and index keys.
+func userItem(user User) (map[string]types.AttributeValue, error) {
+	item, err := attributevalue.MarshalMap(user)
+	if err != nil {
+		return nil, fmt.Errorf("dynamo marshal map, %w", err)
+	}
+	for name, value := range getKey(userKey(user.ID)) {
+		item[name] = value
+	}
+	for name, value := range getKey(userIndexKey(user.ID)) {
+		item[name] = value
+	}
+	return item, nil
+}
+
+// claimEmail returns the transaction step that claims the user's email. It
+// fails with a conditional check if another user holds the email; claiming
+// it again for the same user succeeds.
+func (s *Store) claimEmail(user User) (types.TransactWriteItem, error) {
+	item, err := attributevalue.MarshalMap(emailClaim{UserID: user.ID})
+	if err != nil {
+		return types.TransactWriteItem{}, fmt.Errorf("dynamo marshal map, %w", err)
+	}
+	for name, value := range getKey(emailKey(user.Email)) {
+		item[name] = value
+	}
+
+	cond := expression.AttributeNotExists(expression.Name(pk)).
+		Or(expression.Name("UserID").Equal(expression.Value(user.ID)))
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		return types.TransactWriteItem{}, fmt.Errorf("expression builder: %w", err)
+	}
+
+	return types.TransactWriteItem{
+		Put: &types.Put{
+			TableName:                 aws.String(s.table),
+			Item:                      item,
+			ConditionExpression:       expr.Condition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+		},
+	}, nil
+}
+
+// isTransactionConditionFailed reports whether a transaction was cancelled
+// because the condition of the step at index failed.
+func isTransactionConditionFailed(err error, index int) bool {
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) || index >= len(canceled.CancellationReasons) {
+		return false
+	}
+	return aws.ToString(canceled.CancellationReasons[index].Code) == "ConditionalCheckFailed"
+}
+
+// isTransactionConflict reports whether a transaction was cancelled because
+// the condition of any step failed or another write got in the way.
+func isTransactionConflict(err error) bool {
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		return false
+	}
+	for _, reason := range canceled.CancellationReasons {
+		switch aws.ToString(reason.Code) {
+		case "ConditionalCheckFailed", "TransactionConflict":
+			return true
+		}
+	}
+	return false
+}
+
+// getEmailClaim returns the ID of the user holding the email, or "".
+func (s *Store) getEmailClaim(ctx context.Context, email string) (string, error) {
+	resp, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key:       getKey(emailKey(email)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("dynamo get item, %w", err)
+	}
+	if resp.Item == nil {
+		return "", nil
+	}
+
+	var claim emailClaim
+	err = attributevalue.UnmarshalMap(resp.Item, &claim)
+	if err != nil {
+		return "", fmt.Errorf("unmarshal map, %w", err)
+	}
+	return claim.UserID, nil
+}
+
+// usersByIDVersion is the migration that keys users by ID and claims their
+// emails.
+const usersByIDVersion = 3
+
+// getLegacyUserByEmail reads a user stored before migration 3, whose key is
+// the email as it was entered. Until the migration is applied, an email that
+// differs from the stored one only in case is matched by reading every user,
+// so it cannot be registered a second time.
+func (s *Store) getLegacyUserByEmail(ctx context.Context, email string) (*User, error) {
+	candidates := []string{strings.TrimSpace(email)}
+	if normalized := NormalizeEmail(email); normalized != candidates[0] {
+		candidates = append(candidates, normalized)
+	}
+
+	for _, candidate := range candidates {
+		resp, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName: aws.String(s.table),
+			Key:       getKey(legacyUserKey(candidate)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("dynamo get item, %w", err)
+		}
+		if resp.Item == nil {
+			continue
+		}
+
+		var user User
+		err = attributevalue.UnmarshalMap(resp.Item, &user)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal map, %w", err)
+		}
+		return &user, nil
+	}
+
+	applied, err := s.migrationApplied(ctx, usersByIDVersion)
+	if err != nil {
+		return nil, err
+	}
+	if applied {
+		return nil, nil
+	}
+
+	users, err := s.ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list users, %w", err)
+	}
+	return findUserByEmail(users, email), nil
+}
+
+// findUserByEmail returns the user whose email matches, ignoring case.
+func findUserByEmail(users []User, email string) *User {
+	email = NormalizeEmail(email)
+	for i := range users {
+		if NormalizeEmail(users[i].Email) == email {
+			return &users[i]
+		}
+	}
+	return nil
+}
+
+// getLegacyUserByID finds a user stored before migration 3 through the user
+// index, which migration 1 added to every user.
+func (s *Store) getLegacyUserByID(ctx context.Context, userID string) (*User, error) {
+	indexKey := userIndexKey(userID)
+	keyCond := expression.Key(gsi1pk).Equal(expression.Value(indexKey.Hash.Value)).
+		And(expression.Key(gsi1sk).Equal(expression.Value(indexKey.Sort.Value)))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, fmt.Errorf("expression builder: %w", err)
+	}
+
+	resp, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		TableName:                 aws.String(s.table),
+		IndexName:                 aws.String(gsi1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query, %w", err)
+	}
+
+	var users = []User{}
+
+	err = attributevalue.UnmarshalListOfMaps(resp.Items, &users)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal list of maps, %w", err)
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+
+	return &users[0], nil
+}
+
+// keyUsersByID moves users from their email key to their ID key and claims
+// their emails. Two users whose emails differ only in case cannot both claim
+// theirs; the migration stops so one of them can be deleted or changed.
+func (s *Store) keyUsersByID(ctx context.Context, items []map[string]types.AttributeValue) error {
+	for _, item := range items {
+		var user User
+		err := attributevalue.UnmarshalMap(item, &user)
+		if err != nil {
+			return fmt.Errorf("unmarshal map, %w", err)
+		}
+		if user.ID == "" {
+			continue
+		}
+		user.Email = NormalizeEmail(user.Email)
+
+		claim, err := s.claimEmail(user)
+		if err != nil {
+			return err
+		}
+		newItem, err := userItem(user)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				claim,
+				{Put: &types.Put{TableName: aws.String(s.table), Item: newItem}},
+				{Delete: &types.Delete{
+					TableName: aws.String(s.table),
+					Key:       map[string]types.AttributeValue{pk: item[pk], sk: item[sk]},
+				}},
+			},
+		})
+		if isTransactionConditionFailed(err, 0) {
+			owner, getErr := s.getEmailClaim(ctx, user.Email)
+			if getErr != nil {
+				return getErr
+			}
+			return fmt.Errorf("users %q and %q share the email %q, delete or change one and run the migration again", owner, user.ID, user.Email)
+		}
+		if err != nil {
+			return fmt.Errorf("dynamo transact write items, %w", err)
+		}
+	}
+	return nil
+}
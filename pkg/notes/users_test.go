@@ -0,0 +1,78 @@
+package notes
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	assert.Equal(t, "test@gmail.com", NormalizeEmail("  Test@GMail.com "))
+	assert.Equal(t, emailKey("TEST@gmail.com"), emailKey("test@gmail.com"))
+	assert.Equal(t, "test@gmail.com", emailKey(" Test@gmail.com").Sort.Value)
+}
+
+func TestUserItem(t *testing.T) {
+	item, err := userItem(User{ID: "u1", Email: "test@gmail.com", Name: "Test"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, &types.AttributeValueMemberS{Value: userPrefix}, item[pk])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "u1"}, item[sk])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "user#u1"}, item[gsi1pk])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "test@gmail.com"}, item["Email"])
+}
+
+func TestClaimEmail(t *testing.T) {
+	step, err := NewStore(nil, "notes-test").claimEmail(User{ID: "u1", Email: "Test@gmail.com"})
+	assert.NoError(t, err)
+
+	assert.NotNil(t, step.Put)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: emailPrefix}, step.Put.Item[pk])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "test@gmail.com"}, step.Put.Item[sk])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "u1"}, step.Put.Item["UserID"])
+	assert.Contains(t, aws.ToString(step.Put.ConditionExpression), "attribute_not_exists")
+}
+
+func TestIsTransactionConditionFailed(t *testing.T) {
+	canceled := &types.TransactionCanceledException{
+		CancellationReasons: []types.CancellationReason{
+			{Code: aws.String("ConditionalCheckFailed")},
+			{Code: aws.String("None")},
+		},
+	}
+	err := fmt.Errorf("transact, %w", canceled)
+
+	assert.True(t, isTransactionConditionFailed(err, 0))
+	assert.False(t, isTransactionConditionFailed(err, 1))
+	assert.False(t, isTransactionConditionFailed(err, 2))
+	assert.False(t, isTransactionConditionFailed(fmt.Errorf("other"), 0))
+}
+
+func TestIsTransactionConflict(t *testing.T) {
+	conflict := &types.TransactionCanceledException{
+		CancellationReasons: []types.CancellationReason{
+			{Code: aws.String("None")},
+			{Code: aws.String("ConditionalCheckFailed")},
+		},
+	}
+	throttled := &types.TransactionCanceledException{
+		CancellationReasons: []types.CancellationReason{
+			{Code: aws.String("ThrottlingError")},
+		},
+	}
+
+	assert.True(t, isTransactionConflict(fmt.Errorf("transact, %w", conflict)))
+	assert.False(t, isTransactionConflict(fmt.Errorf("transact, %w", throttled)))
+	assert.False(t, isTransactionConflict(fmt.Errorf("other")))
+}
+
+func TestFindUserByEmail(t *testing.T) {
+	users := []User{{ID: "u1", Email: "Ann@Example.com"}, {ID: "u2", Email: "bo@example.com"}}
+
+	assert.Equal(t, "u1", findUserByEmail(users, " ann@example.COM").ID)
+	assert.Equal(t, "u2", findUserByEmail(users, "Bo@example.com").ID)
+	assert.Nil(t, findUserByEmail(users, "cy@example.com"))
+}
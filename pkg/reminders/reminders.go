@@ -0,0 +1,113 @@
+// Package reminders sends notifications for notes whose reminder time has
+// passed. It is driven by a scheduled EventBridge rule; see cmd/reminders.
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/logging"
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+)
+
+// batchSize is how many due reminders are read per query.
+const batchSize = 100
+
+// Store finds due reminders and records them as sent.
+type Store interface {
+	DueReminders(ctx context.Context, before time.Time, after string, limit int32) ([]notes.Reminder, string, error)
+	MarkReminded(ctx context.Context, reminder notes.Reminder, at time.Time) error
+}
+
+// Notifier delivers a single reminder to its user.
+type Notifier interface {
+	Notify(ctx context.Context, reminder notes.Reminder) error
+}
+
+// Clock tells the processor what time it is.
+type Clock interface {
+	Now() time.Time
+}
+
+// Result summarises one run of the processor.
+type Result struct {
+	Sent   int
+	Failed int
+}
+
+// Processor sends every reminder that is due. Reminders that fail to send are
+// left in place and retried on the next run.
+type Processor struct {
+	Store    Store
+	Notifier Notifier
+	Clock    Clock
+}
+
+// New returns a processor backed by the notes table, logging notifications
+// and using the system clock.
+func New(store *notes.Store) *Processor {
+	return &Processor{
+		Store:    store,
+		Notifier: LogNotifier{},
+		Clock:    SystemClock{},
+	}
+}
+
+// Run sends the reminders due at the clock's current time. Each batch is
+// read from the cursor where the previous one ended, so reminders that fail
+// to send do not hold up those behind them.
+func (p *Processor) Run(ctx context.Context) (Result, error) {
+	now := p.Clock.Now()
+	result := Result{}
+	after := ""
+
+	for {
+		reminders, next, err := p.Store.DueReminders(ctx, now, after, batchSize)
+		if err != nil {
+			return result, fmt.Errorf("due reminders, %w", err)
+		}
+
+		for _, reminder := range reminders {
+			err = p.Notifier.Notify(ctx, reminder)
+			if err == nil {
+				err = p.Store.MarkReminded(ctx, reminder, now)
+			}
+			if err != nil {
+				logging.FromContext(ctx).WarnContext(ctx, "reminder failed", "reminder", reminderID(reminder), "error", err)
+				result.Failed++
+				continue
+			}
+
+			result.Sent++
+		}
+
+		if next == "" {
+			return result, nil
+		}
+		after = next
+	}
+}
+
+func reminderID(reminder notes.Reminder) string {
+	return fmt.Sprintf("%s/%s/%s@%s", reminder.UserID, reminder.TopicTitle, reminder.NoteTitle, reminder.RemindAt.Format(time.RFC3339))
+}
+
+// LogNotifier writes reminders to the log, which ends up in CloudWatch.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, reminder notes.Reminder) error {
+	attrs := []any{"user", reminder.UserID, "topic", reminder.TopicTitle, "note", reminder.NoteTitle}
+	if reminder.DueAt != nil {
+		attrs = append(attrs, "dueAt", reminder.DueAt.Format(time.RFC3339))
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "reminder", attrs...)
+	return nil
+}
+
+// SystemClock is the real wall clock.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now().UTC()
+}
@@ -0,0 +1,162 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/KyleJonesNV/go-service-notes/pkg/notes"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c fakeClock) Now() time.Time {
+	return c.now
+}
+
+type fakeNotifier struct {
+	sent []string
+	fail map[string]bool
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, reminder notes.Reminder) error {
+	if n.fail[reminder.NoteTitle] {
+		return errors.New("notifier down")
+	}
+	n.sent = append(n.sent, reminder.NoteTitle)
+	return nil
+}
+
+type fakeStore struct {
+	pending  []notes.Reminder
+	reminded map[string]time.Time
+	// pageSize, when set, cuts pages short like the 1 MB limit of a query.
+	pageSize int
+}
+
+func (s *fakeStore) DueReminders(ctx context.Context, before time.Time, after string, limit int32) ([]notes.Reminder, string, error) {
+	due := []notes.Reminder{}
+	for _, reminder := range s.pending {
+		if !reminder.RemindAt.After(before) && sortKey(reminder) > after {
+			due = append(due, reminder)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return sortKey(due[i]) < sortKey(due[j])
+	})
+
+	size := int(limit)
+	if s.pageSize > 0 && s.pageSize < size {
+		size = s.pageSize
+	}
+	if len(due) <= size {
+		return due, "", nil
+	}
+	return due[:size], sortKey(due[size-1]), nil
+}
+
+// sortKey orders reminders the way the reminder partition does.
+func sortKey(reminder notes.Reminder) string {
+	return reminder.RemindAt.UTC().Format(time.RFC3339) + "#" + reminder.UserID + "#" + reminder.TopicTitle + "#" + reminder.NoteTitle
+}
+
+func (s *fakeStore) MarkReminded(ctx context.Context, reminder notes.Reminder, at time.Time) error {
+	s.reminded[reminder.NoteTitle] = at
+	for i := range s.pending {
+		if s.pending[i].NoteTitle == reminder.NoteTitle {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func TestProcessorRun(t *testing.T) {
+	now := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	store := &fakeStore{
+		pending: []notes.Reminder{
+			{UserID: "u1", TopicTitle: "Work", NoteTitle: "overdue", RemindAt: now.Add(-time.Hour)},
+			{UserID: "u1", TopicTitle: "Work", NoteTitle: "now", RemindAt: now},
+			{UserID: "u2", TopicTitle: "Home", NoteTitle: "broken", RemindAt: now.Add(-time.Minute)},
+			{UserID: "u2", TopicTitle: "Home", NoteTitle: "later", RemindAt: now.Add(time.Minute)},
+		},
+		reminded: map[string]time.Time{},
+	}
+	notifier := &fakeNotifier{fail: map[string]bool{"broken": true}}
+
+	processor := Processor{Store: store, Notifier: notifier, Clock: fakeClock{now}}
+
+	result, err := processor.Run(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Sent: 2, Failed: 1}, result)
+	sort.Strings(notifier.sent)
+	assert.Equal(t, []string{"now", "overdue"}, notifier.sent)
+	assert.Equal(t, map[string]time.Time{"overdue": now, "now": now}, store.reminded)
+
+	titles := []string{}
+	for _, reminder := range store.pending {
+		titles = append(titles, reminder.NoteTitle)
+	}
+	assert.Equal(t, []string{"broken", "later"}, titles)
+}
+
+func TestProcessorRun_ManyBatches(t *testing.T) {
+	now := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	store := &fakeStore{reminded: map[string]time.Time{}}
+	for i := 0; i < batchSize*2+5; i++ {
+		store.pending = append(store.pending, notes.Reminder{NoteTitle: time.Duration(i).String(), RemindAt: now})
+	}
+	notifier := &fakeNotifier{}
+
+	processor := Processor{Store: store, Notifier: notifier, Clock: fakeClock{now}}
+
+	result, err := processor.Run(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, batchSize*2+5, result.Sent)
+	assert.Empty(t, store.pending)
+}
+
+func TestProcessorRun_FailingBatch(t *testing.T) {
+	now := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	store := &fakeStore{reminded: map[string]time.Time{}}
+	notifier := &fakeNotifier{fail: map[string]bool{}}
+	for i := 0; i < batchSize+10; i++ {
+		title := fmt.Sprintf("failing %03d", i)
+		store.pending = append(store.pending, notes.Reminder{NoteTitle: title, RemindAt: now.Add(-time.Hour)})
+		notifier.fail[title] = true
+	}
+	store.pending = append(store.pending, notes.Reminder{NoteTitle: "behind", RemindAt: now})
+
+	processor := Processor{Store: store, Notifier: notifier, Clock: fakeClock{now}}
+
+	result, err := processor.Run(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Sent: 1, Failed: batchSize + 10}, result)
+	assert.Equal(t, []string{"behind"}, notifier.sent)
+	assert.Len(t, store.pending, batchSize+10)
+}
+
+func TestProcessorRun_ShortPages(t *testing.T) {
+	now := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	store := &fakeStore{reminded: map[string]time.Time{}, pageSize: 7}
+	for i := 0; i < 30; i++ {
+		store.pending = append(store.pending, notes.Reminder{NoteTitle: fmt.Sprintf("note %02d", i), RemindAt: now})
+	}
+
+	processor := Processor{Store: store, Notifier: &fakeNotifier{}, Clock: fakeClock{now}}
+
+	result, err := processor.Run(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Sent: 30}, result)
+	assert.Empty(t, store.pending)
+}
@@ -6,5 +6,11 @@ GOOS=linux GOARCH=amd64 go build -o main main.go
 echo "Create a ZIP file"
 zip deployment.zip main
 
+echo "Build the reminders binary"
+GOOS=linux GOARCH=amd64 go build -o main ./cmd/reminders
+
+echo "Create the reminders ZIP file"
+zip reminders.zip main
+
 echo "Cleaning up"
 rm main
\ No newline at end of file